@@ -8,70 +8,80 @@ import (
 	"github.com/maltegrosse/go-bytesize"
 )
 
+// DefaultTimeLayout is the layout of time.Time used without WithTimeLayouts.
+const DefaultTimeLayout = "Mon Jan 2 15:04:05 -0700 MST 2006"
+
 // TimeLayout default time layout for convert To time.Time
-var TimeLayout = "Mon Jan 2 15:04:05 -0700 MST 2006"
+//
+// Deprecated: TimeLayout is shared by every user of the package, and only read
+// by the package-level functions. Use NewConverter with WithTimeLayouts instead.
+var TimeLayout = DefaultTimeLayout
 
 // ByteSize type of byte size
 type ByteSize bytesize.ByteSize
 
+var (
+	defaultConverter     = newDefaultConverter()
+	defaultWeakConverter = newDefaultConverter(WithWeak(true))
+)
+
+// newDefaultConverter returns a Converter of the package-level functions,
+// honoring TimeLayout.
+func newDefaultConverter(opts ...Option) *Converter {
+	c := NewConverter(opts...)
+	c.globalTimeLayout = true
+	return c
+}
+
 // To convert to src to dst
 func To(src, dst interface{}) error {
-	return to(src, dst)
+	return defaultConverter.To(src, dst)
 }
 
 // WeakTo convert to src to dst (weak type convert)
 func WeakTo(src, dst interface{}) error {
-	return weakTo(src, dst)
+	return defaultWeakConverter.To(src, dst)
 }
 
-func to(src, dst interface{}) error {
+func (d *decoder) to(src, dst interface{}) error {
 	dstv := reflect.ValueOf(dst)
 	if dstv.Kind() != reflect.Ptr {
 		return errors.New("non-pointer of dst")
 	}
 	srcv := reflect.ValueOf(src)
 
-	return to0(srcv, dstv.Elem())
+	return d.to0(srcv, dstv.Elem())
 }
 
-func to0(src, dst reflect.Value) (err error) {
+func (d *decoder) to0(src, dst reflect.Value) error {
 	if !dst.CanSet() {
-		return &CannotSetError{}
+		return &CannotSetError{Type: dst.Type()}
+	}
+	if err := d.enter(); err != nil {
+		return err
 	}
+	defer d.leave()
 
-	switch dst.Type().PkgPath() {
-	case "time":
-		switch dst.Type().Name() {
-		case "Duration":
-			return toTimeDuration(src, dst)
-		case "Time":
-			return toTimeTime(src, dst)
-		}
-	case "net":
-		switch dst.Type().Name() {
-		case "IP":
-			return toNetIP(src, dst)
-		case "HardwareAddr":
-			return toNetHardwareAddr(src, dst)
-		}
-	case "net/url":
-		if dst.Type().Name() == "URL" {
-			return toNetURL(src, dst)
-		}
-	case "net/mail":
-		if dst.Type().Name() == "Address" {
-			return toMailAddress(src, dst)
-		}
-	case "regexp":
-		if dst.Type().Name() == "Regexp" {
-			return toRegexpRegexp(src, dst)
-		}
-	case "github.com/helloyi/go-conv":
-		if dst.Type().Name() == "ByteSize" {
-			return toByteSize(src, dst)
+	src, err := d.runHooks(src, dst.Type())
+	if err != nil {
+		return err
+	}
+	if isNull(src) {
+		return d.toNull(dst)
+	}
+	if d.deepCopy {
+		if ok, err := d.copyTo(src, dst); ok {
+			return err
 		}
 	}
 
+	if fn, ok := d.lookupConv(false, dst.Type()); ok {
+		return fn(d, src, dst)
+	}
+	if ok, err := unmarshal(src, dst); ok {
+		return err
+	}
+
 	switch dst.Kind() {
 	case reflect.Bool:
 		return toBool(src, dst)
@@ -89,79 +99,70 @@ func to0(src, dst reflect.Value) (err error) {
 		return toComplex(src, dst)
 
 	case reflect.Array:
-		return toArray(src, dst)
+		return d.toArray(src, dst)
 
 	case reflect.Interface:
-		return toInterface(src, dst)
+		return d.toInterface(src, dst)
 
 	case reflect.Map:
-		return toMap(src, dst)
+		return d.toMap(src, dst)
 
 	case reflect.Ptr:
-		return toPtr(src, dst)
+		return d.toPtr(src, dst)
 
 	case reflect.Slice:
-		return toSlice(src, dst)
+		return d.toSlice(src, dst)
 
 	case reflect.String:
 		return toString(src, dst)
 
 	case reflect.Struct:
-		return toStruct(src, dst)
+		return d.toStruct(src, dst)
 
 	default:
 		return &CannotConvError{src.Kind(), dst.Kind()}
 	}
 }
 
-func weakTo(src, dst interface{}) error {
+func (d *decoder) weakTo(src, dst interface{}) error {
 	dstv := reflect.ValueOf(dst)
 	if dstv.Kind() != reflect.Ptr {
 		return errors.New("non-pointer of dst")
 	}
 	srcv := reflect.ValueOf(src)
 
-	return weakTo0(srcv, dstv.Elem())
+	return d.weakTo0(srcv, dstv.Elem())
 }
 
-func weakTo0(src, dst reflect.Value) error {
+func (d *decoder) weakTo0(src, dst reflect.Value) error {
 	if !dst.CanSet() {
-		return &CannotSetError{}
+		return &CannotSetError{Type: dst.Type()}
 	}
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
 
-	switch dst.Type().PkgPath() {
-	case "time":
-		switch dst.Type().Name() {
-		case "Duration":
-			return weakToTimeDuration(src, dst)
-		case "Time":
-			return weakToTimeTime(src, dst)
-		}
-	case "net":
-		switch dst.Type().Name() {
-		case "IP":
-			return weakToNetIP(src, dst)
-		case "HardwareAddr":
-			return weakToNetHardwareAddr(src, dst)
-		}
-	case "net/url":
-		if dst.Type().Name() == "URL" {
-			return weakToNetURL(src, dst)
-		}
-	case "net/mail":
-		if dst.Type().Name() == "Address" {
-			return weakToMailAddress(src, dst)
-		}
-	case "regexp":
-		if dst.Type().Name() == "Regexp" {
-			return weakToRegexpRegexp(src, dst)
-		}
-	case "github.com/helloyi/go-conv":
-		if dst.Type().Name() == "ByteSize" {
-			return weakToByteSize(src, dst)
+	src, err := d.runHooks(src, dst.Type())
+	if err != nil {
+		return err
+	}
+	if isNull(src) {
+		return d.toNull(dst)
+	}
+	if d.deepCopy {
+		if ok, err := d.copyTo(src, dst); ok {
+			return err
 		}
 	}
 
+	if fn, ok := d.lookupConv(true, dst.Type()); ok {
+		return fn(d, src, dst)
+	}
+	if ok, err := unmarshal(src, dst); ok {
+		return err
+	}
+
 	switch dst.Kind() {
 	case reflect.Bool:
 		return weakToBool(src, dst)
@@ -182,24 +183,37 @@ func weakTo0(src, dst reflect.Value) error {
 		return weakToString(src, dst)
 
 	case reflect.Array:
-		return weakToArray(src, dst)
+		return d.weakToArray(src, dst)
 
 	case reflect.Interface:
-		return weakToInterface(src, dst)
+		return d.weakToInterface(src, dst)
 
 	case reflect.Map:
-		return weakToMap(src, dst)
+		return d.weakToMap(src, dst)
 
 	case reflect.Ptr:
-		return weakToPtr(src, dst)
+		return d.weakToPtr(src, dst)
 
 	case reflect.Slice:
-		return weakToSlice(src, dst)
+		return d.weakToSlice(src, dst)
 
 	case reflect.Struct:
-		return weakToStruct(src, dst)
+		return d.weakToStruct(src, dst)
 
 	default:
 		return &CannotConvError{src.Kind(), dst.Kind()}
 	}
 }
+
+// toNull converts a null source to dst according to the NullPolicy.
+func (d *decoder) toNull(dst reflect.Value) error {
+	switch d.nullPolicy {
+	case NullSkip:
+		return nil
+	case NullError:
+		return ErrNull
+	default:
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+}
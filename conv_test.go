@@ -1,10 +1,18 @@
 package conv
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
+	"math/big"
 	"math/bits"
+	"net"
+	"net/url"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 	"unsafe"
@@ -245,8 +253,10 @@ func TestToMap_composite(t *testing.T) {
 	assert.Equal(t, expected2, dst2)
 
 	srcStruct := struct {
-		a, b int
-	}{1, 2}
+		A int `conv:"a"`
+		B int `conv:"b"`
+		c int
+	}{1, 2, 3}
 	var dst3 map[string]int
 	expected3 := map[string]int{"a": 1, "b": 2}
 	err = To(srcStruct, &dst3)
@@ -254,6 +264,260 @@ func TestToMap_composite(t *testing.T) {
 	assert.Equal(t, expected3, dst3)
 }
 
+func TestToMap_keys(t *testing.T) {
+	var dst map[int]string
+	err := WeakTo(map[string]interface{}{"1": "a", "2": 3}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, map[int]string{1: "a", 2: "3"}, dst)
+
+	var dst1 map[int64]int
+	err = To(map[int]int{1: 1}, &dst1)
+	require.Nil(t, err)
+	assert.Equal(t, map[int64]int{1: 1}, dst1)
+
+	var dst2 map[time.Duration]int
+	err = To(map[string]interface{}{"1s": 1}, &dst2)
+	require.Nil(t, err)
+	assert.Equal(t, map[time.Duration]int{time.Second: 1}, dst2)
+
+	var dst3 map[string]int
+	err = WeakTo([]int{1, 2}, &dst3)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]int{"0": 1, "1": 2}, dst3)
+
+	var dst4 map[interface{}]int
+	err = To(struct{ A int }{1}, &dst4)
+	require.Nil(t, err)
+	assert.Equal(t, map[interface{}]int{"A": 1}, dst4)
+
+	var dst5 map[int]string
+	err = To(map[string]interface{}{"x": "a"}, &dst5)
+	var fe *FieldError
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, "x", fe.Path)
+	assert.Equal(t, reflect.TypeOf(0), fe.DstType)
+}
+
+func TestArrayLenPolicy(t *testing.T) {
+	dst := [3]int{7, 8, 9}
+	err := To([]int{1, 2}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, [3]int{1, 2, 0}, dst)
+
+	err = To([]int{1, 2, 3, 4}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, [3]int{1, 2, 3}, dst)
+
+	c := NewConverter(WithArrayLenPolicy(ArrayTruncate))
+	dst = [3]int{7, 8, 9}
+	err = c.To([]int{1, 2}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, [3]int{1, 2, 0}, dst)
+
+	err = c.To(struct{ A, B, C, D int }{1, 2, 3, 4}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, [3]int{1, 2, 3}, dst)
+
+	c = NewConverter(WithArrayLenPolicy(ArrayPad))
+	dst = [3]int{7, 8, 9}
+	err = c.To(1, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, [3]int{1, 0, 0}, dst)
+
+	dst = [3]int{7, 8, 9}
+	err = c.To([]int{1, 2, 3, 4}, &dst)
+	assert.Equal(t, &ArrayLenError{4, 3}, err)
+	assert.Equal(t, [3]int{7, 8, 9}, dst)
+
+	c = NewConverter(WithArrayLenPolicy(ArrayStrict))
+	dst = [3]int{7, 8, 9}
+	err = c.To([]int{1, 2}, &dst)
+	assert.Equal(t, &ArrayLenError{2, 3}, err)
+	assert.Equal(t, [3]int{7, 8, 9}, dst)
+
+	err = c.To(1, &dst)
+	assert.Equal(t, &ArrayLenError{1, 3}, err)
+
+	var nested struct {
+		Pos [2]float64
+	}
+	err = c.To(map[string]interface{}{"pos": []float64{1}}, &nested)
+	assert.Equal(t, "pos: cannot convert 1 elements to an array of length 2", err.Error())
+
+	err = c.To([3]int{1, 2, 3}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, [3]int{1, 2, 3}, dst)
+}
+
+func TestSliceMergeMode(t *testing.T) {
+	type server struct {
+		Host string
+		Port int
+	}
+	defaults := func() []server {
+		return []server{{"a", 80}, {"b", 81}, {"c", 82}}
+	}
+	src := []interface{}{map[string]interface{}{"port": 90}}
+
+	dst := defaults()
+	err := To(src, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, []server{{Port: 90}}, dst)
+
+	dst = defaults()
+	err = NewConverter(WithSliceMergeMode(SliceAppend)).To(src, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, append(defaults(), server{Port: 90}), dst)
+
+	dst = defaults()
+	err = NewConverter(WithSliceMergeMode(SliceMergeByIndex)).To(src, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, []server{{"a", 90}, {"b", 81}, {"c", 82}}, dst)
+
+	ints := []int{1}
+	err = NewConverter(WithSliceMergeMode(SliceMergeByIndex)).To([]int{5, 6}, &ints)
+	require.Nil(t, err)
+	assert.Equal(t, []int{5, 6}, ints)
+
+	ints = []int{1}
+	err = NewConverter(WithSliceMergeMode(SliceAppend)).To(2, &ints)
+	require.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, ints)
+
+	var nilInts []int
+	err = NewConverter(WithSliceMergeMode(SliceAppend)).To([2]int{1, 2}, &nilInts)
+	require.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, nilInts)
+}
+
+func TestMapMergeMode(t *testing.T) {
+	type tls struct {
+		Cert string
+		Key  string
+	}
+	type config struct {
+		Labels map[string]string
+		TLS    map[string]tls
+		Extra  map[string]interface{}
+	}
+	defaults := func() config {
+		return config{
+			Labels: map[string]string{"env": "dev", "team": "a"},
+			TLS:    map[string]tls{"web": {"a.pem", "a.key"}},
+			Extra: map[string]interface{}{
+				"db": map[string]interface{}{"host": "localhost", "port": 5432},
+			},
+		}
+	}
+	override := map[string]interface{}{
+		"labels": map[string]interface{}{"env": "prod"},
+		"tls":    map[string]interface{}{"web": map[string]interface{}{"cert": "b.pem"}},
+		"extra": map[string]interface{}{
+			"db": map[string]interface{}{"host": "db"},
+		},
+	}
+
+	dst := defaults()
+	err := To(override, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, config{
+		Labels: map[string]string{"env": "prod"},
+		TLS:    map[string]tls{"web": {Cert: "b.pem"}},
+		Extra:  map[string]interface{}{"db": map[string]interface{}{"host": "db"}},
+	}, dst)
+
+	dst = defaults()
+	err = NewConverter(WithMapMergeMode(MapMergeShallow)).To(override, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, config{
+		Labels: map[string]string{"env": "prod", "team": "a"},
+		TLS:    map[string]tls{"web": {Cert: "b.pem"}},
+		Extra:  map[string]interface{}{"db": map[string]interface{}{"host": "db"}},
+	}, dst)
+
+	dst = defaults()
+	err = NewConverter(WithMapMergeMode(MapMergeDeep)).To(override, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, config{
+		Labels: map[string]string{"env": "prod", "team": "a"},
+		TLS:    map[string]tls{"web": {"b.pem", "a.key"}},
+		Extra: map[string]interface{}{
+			"db": map[string]interface{}{"host": "db", "port": 5432},
+		},
+	}, dst)
+
+	m := map[string]interface{}{"a": map[string]interface{}{"x": 1}}
+	err = NewConverter(WithMapMergeMode(MapMergeDeep)).To(map[string]interface{}{"a": 2}, &m)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 2}, m)
+}
+
+func TestNullPolicy(t *testing.T) {
+	type config struct {
+		Name    string
+		Port    *int
+		Tags    []string
+		Labels  map[string]string
+		Extra   interface{}
+		Timeout time.Duration
+		Created time.Time
+	}
+	port := 80
+	full := func() config {
+		return config{
+			Name:    "x",
+			Port:    &port,
+			Tags:    []string{"a"},
+			Labels:  map[string]string{"a": "b"},
+			Extra:   1,
+			Timeout: time.Second,
+			Created: time.Unix(1, 0),
+		}
+	}
+	src := map[string]interface{}{
+		"name":    nil,
+		"port":    (*int)(nil),
+		"tags":    nil,
+		"labels":  nil,
+		"extra":   nil,
+		"timeout": nil,
+		"created": nil,
+	}
+
+	for _, weak := range []bool{false, true} {
+		dst := full()
+		err := NewConverter(WithWeak(weak)).To(src, &dst)
+		require.Nil(t, err)
+		assert.Equal(t, config{}, dst)
+
+		dst = full()
+		err = NewConverter(WithWeak(weak), WithNullPolicy(NullSkip)).To(src, &dst)
+		require.Nil(t, err)
+		assert.Equal(t, full(), dst)
+
+		dst = full()
+		err = NewConverter(WithWeak(weak), WithNullPolicy(NullError)).To(map[string]interface{}{"name": nil}, &dst)
+		assert.True(t, errors.Is(err, ErrNull))
+		assert.Equal(t, "name: cannot convert null", err.Error())
+	}
+
+	s := "x"
+	err := To(nil, &s)
+	require.Nil(t, err)
+	assert.Equal(t, "", s)
+
+	var ptr **int
+	err = WeakTo(nil, &ptr)
+	require.Nil(t, err)
+	assert.Nil(t, ptr)
+
+	// the default of the Or functions rather than the zero value
+	m := map[string]interface{}{"timeout": nil}
+	assert.Equal(t, 30*time.Second, ToDurationOr(m["missing"], 30*time.Second))
+	assert.Equal(t, 30*time.Second, WeakToDurationOr(m["timeout"], 30*time.Second))
+	assert.Equal(t, int64(80), ToInt64Or((*int)(nil), 80))
+}
+
 type testStringer struct{}
 type testStringStruct struct{}
 
@@ -345,7 +609,7 @@ func TestWeakToBool(t *testing.T) {
 
 	for _, test := range succTests {
 		var dst bool
-		err := weakTo(test.src, &dst)
+		err := WeakTo(test.src, &dst)
 		require.Nil(t, err)
 		assert.Equal(t, test.expected, dst)
 	}
@@ -381,7 +645,7 @@ func TestWeakToTimeDuration(t *testing.T) {
 type TestTime time.Time
 
 func TestWeakToTimeTime(t *testing.T) {
-	x := time.Now()
+	x := time.Now().Truncate(time.Second).UTC()
 	dt := x.Format(TimeLayout)
 	succTests := []struct {
 		src      interface{}
@@ -439,7 +703,7 @@ func TestIsOverflowInt(t *testing.T) {
 
 	for _, test := range failureTests {
 		for _, dst := range dsts {
-			src := reflect.ValueOf(test)
+			src := reflect.ValueOf(test.src)
 			assert.True(t, isOverflowInt(src, dst))
 		}
 	}
@@ -579,6 +843,401 @@ func TestIsOverflowComplex(t *testing.T) {
 	}
 }
 
+func TestWeakToPtr(t *testing.T) {
+	var dst struct {
+		URL     *url.URL
+		Created *time.Time
+		Pattern *regexp.Regexp
+		Port    *int
+		Extra   interface{}
+	}
+	port := 1
+	dst.Port = &port
+
+	c := NewConverter(WithWeak(true), WithTimeLayouts(time.RFC3339))
+	err := c.To(map[string]interface{}{
+		"url":     "https://example.com/a",
+		"created": "2021-01-02T03:04:05Z",
+		"pattern": "^a+$",
+		"port":    "80",
+		"extra":   []int{1},
+	}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, "example.com", dst.URL.Host)
+	assert.Equal(t, 2021, dst.Created.Year())
+	assert.True(t, dst.Pattern.MatchString("aa"))
+	assert.Equal(t, 80, port) // reused
+	assert.Equal(t, &port, dst.Port)
+	assert.Equal(t, []int{1}, dst.Extra)
+
+	var pp **int
+	err = WeakTo("2", &pp)
+	require.Nil(t, err)
+	assert.Equal(t, 2, **pp)
+
+	err = WeakTo("x", &pp)
+	assert.NotNil(t, err)
+}
+
+type testPtrStringer struct{ s string }
+
+func (p *testPtrStringer) String() string {
+	return p.s
+}
+
+func TestToInterface(t *testing.T) {
+	var stringer fmt.Stringer
+	err := To(time.Second, &stringer)
+	require.Nil(t, err)
+	assert.Equal(t, "1s", stringer.String())
+
+	err = WeakTo(testPtrStringer{"x"}, &stringer)
+	require.Nil(t, err)
+	assert.Equal(t, "x", stringer.String())
+
+	err = To(1, &stringer)
+	var ie *InterfaceError
+	require.True(t, errors.As(err, &ie))
+	assert.Equal(t, "int does not implement fmt.Stringer", err.Error())
+
+	var dst struct {
+		W fmt.Stringer
+	}
+	err = To(map[string]interface{}{"w": 1}, &dst)
+	assert.Equal(t, "w: int does not implement fmt.Stringer", err.Error())
+
+	src := map[string]interface{}{"a": []int{1}}
+	var alias, cp interface{}
+	err = To(src, &alias)
+	require.Nil(t, err)
+	err = NewConverter(WithDeepCopy(true)).To(src, &cp)
+	require.Nil(t, err)
+	src["a"].([]int)[0] = 2
+	src["b"] = 1
+	assert.Equal(t, src, alias)
+	assert.Equal(t, map[string]interface{}{"a": []int{1}}, cp)
+}
+
+type testSnapshot struct {
+	Name    string
+	Servers []*testServer
+	Labels  map[string][]string
+	Extra   interface{}
+	Pattern *regexp.Regexp
+	Skipped string `conv:"-"`
+	secret  int
+}
+
+func TestDeepCopy(t *testing.T) {
+	src := testSnapshot{
+		Name:    "x",
+		Servers: []*testServer{{Host: "a", Timeout: 80}},
+		Labels:  map[string][]string{"a": {"b"}},
+		Extra:   map[string]interface{}{"n": []int{1}},
+		Pattern: regexp.MustCompile("^a+$"),
+		Skipped: "s",
+		secret:  1,
+	}
+
+	cp := DeepCopy(src)
+	assert.Equal(t, src, cp)
+	cp.Servers[0].Timeout = 81
+	cp.Labels["a"][0] = "c"
+	cp.Extra.(map[string]interface{})["n"].([]int)[0] = 2
+	assert.Equal(t, time.Duration(80), src.Servers[0].Timeout)
+	assert.Equal(t, "b", src.Labels["a"][0])
+	assert.Equal(t, []int{1}, src.Extra.(map[string]interface{})["n"])
+	assert.True(t, cp.Pattern.MatchString("aa"))
+	assert.NotSame(t, src.Pattern, cp.Pattern)
+
+	var dst testSnapshot
+	err := Clone(&src, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, src, dst)
+	dst.Servers[0].Timeout = 82
+	assert.Equal(t, time.Duration(80), src.Servers[0].Timeout)
+
+	m := map[string]interface{}{
+		"name":   "y",
+		"labels": map[string][]string{"a": {"b"}},
+		"extra":  []interface{}{map[string]interface{}{"k": 1}},
+	}
+	var fromMap testSnapshot
+	err = Clone(m, &fromMap)
+	require.Nil(t, err)
+	fromMap.Labels["a"][0] = "c"
+	fromMap.Extra.([]interface{})[0].(map[string]interface{})["k"] = 2
+	assert.Equal(t, map[string]interface{}{
+		"name":   "y",
+		"labels": map[string][]string{"a": {"b"}},
+		"extra":  []interface{}{map[string]interface{}{"k": 1}},
+	}, m)
+
+	assert.Nil(t, DeepCopy[interface{}](nil))
+	assert.Nil(t, DeepCopy[map[string]int](nil))
+	assert.Equal(t, [2][]int{{1}, {2}}, DeepCopy([2][]int{{1}, {2}}))
+
+	// unexported fields holding references can't be copied
+	type refs struct {
+		Name   string
+		secret []int
+	}
+	var rdst refs
+	err = Clone(refs{"x", []int{1}}, &rdst)
+	var ce *CannotCopyError
+	require.True(t, errors.As(err, &ce))
+	assert.Equal(t, "secret", ce.Field)
+	assert.Equal(t, "cannot copy unexported field secret of conv.refs", err.Error())
+	assert.Panics(t, func() { DeepCopy(refs{}) })
+
+	stringer := struct{ S fmt.Stringer }{&testPtrStringer{"x"}}
+	scp := DeepCopy(stringer)
+	assert.Equal(t, "x", scp.S.String())
+	assert.NotSame(t, stringer.S, scp.S)
+
+	ip := net.ParseIP("::1")
+	ipcp := DeepCopy(ip)
+	ipcp[0] = 1
+	assert.Equal(t, "::1", ip.String())
+}
+
+type testNode struct {
+	Name     string
+	Parent   *testNode
+	Children []*testNode
+	Meta     map[string]interface{}
+}
+
+type testTree map[string]testTree
+
+func TestCycles(t *testing.T) {
+	root := &testNode{Name: "root"}
+	child := &testNode{Name: "child", Parent: root}
+	root.Children = []*testNode{child, child}
+
+	var dst *testNode
+	err := To(root, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, "child", dst.Children[0].Name)
+	assert.Same(t, dst, dst.Children[0].Parent)
+	assert.Same(t, dst.Children[0], dst.Children[1])
+	assert.NotSame(t, root, dst)
+
+	cp := DeepCopy(root)
+	assert.Same(t, cp, cp.Children[0].Parent)
+	assert.Same(t, cp.Children[0], cp.Children[1])
+	assert.NotSame(t, root, cp)
+
+	m := map[string]interface{}{"name": "m"}
+	m["self"] = m
+	mcp := DeepCopy(m)
+	assert.Equal(t, reflect.ValueOf(mcp).Pointer(), reflect.ValueOf(mcp["self"]).Pointer())
+	assert.NotEqual(t, reflect.ValueOf(m).Pointer(), reflect.ValueOf(mcp).Pointer())
+
+	tree := testTree{}
+	tree["a"] = tree
+	var tdst testTree
+	err = To(tree, &tdst)
+	require.Nil(t, err)
+	assert.Equal(t, reflect.ValueOf(tdst).Pointer(), reflect.ValueOf(tdst["a"]).Pointer())
+
+	_, err = ToMap(root)
+	var de *MaxDepthError
+	require.True(t, errors.As(err, &de))
+	assert.Equal(t, DefaultMaxDepth, de.MaxDepth)
+}
+
+func TestSharedReferences(t *testing.T) {
+	s := []int{7}
+	type ints struct{ A, B []int }
+
+	dst := ints{A: []int{1}, B: []int{2}}
+	err := NewConverter(WithSliceMergeMode(SliceAppend)).To(map[string]interface{}{"a": s, "b": s}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, ints{A: []int{1, 7}, B: []int{2, 7}}, dst)
+
+	// no backing array shared by the copies
+	var fresh ints
+	err = To(map[string]interface{}{"a": s, "b": s}, &fresh)
+	require.Nil(t, err)
+	fresh.A[0] = 8
+	assert.Equal(t, []int{7}, fresh.B)
+	assert.Equal(t, []int{7}, s)
+
+	m := map[string]int{"a": 1}
+	type maps struct{ A, B map[string]int }
+	mdst := maps{A: map[string]int{"x": 1}, B: map[string]int{"y": 2}}
+	err = NewConverter(WithMapMergeMode(MapMergeShallow)).To(map[string]interface{}{"a": m, "b": m}, &mdst)
+	require.Nil(t, err)
+	assert.Equal(t, maps{A: map[string]int{"x": 1, "a": 1}, B: map[string]int{"y": 2, "a": 1}}, mdst)
+
+	// existing pointers are converted into, not replaced
+	type server struct {
+		Host string
+		Port int
+	}
+	p := &server{Host: "a"}
+	type ptrs struct{ A, B *server }
+	a, b := &server{Port: 1}, &server{Port: 2}
+	pdst := ptrs{A: a, B: b}
+	err = To(map[string]interface{}{"a": p, "b": p}, &pdst)
+	require.Nil(t, err)
+	assert.Same(t, a, pdst.A)
+	assert.Same(t, b, pdst.B)
+	assert.Equal(t, "a", pdst.B.Host)
+
+	var pfresh ptrs
+	err = To(map[string]interface{}{"a": p, "b": p}, &pfresh)
+	require.Nil(t, err)
+	assert.Same(t, pfresh.A, pfresh.B)
+	assert.NotSame(t, p, pfresh.A)
+
+	// cycles converted twice into the same destination
+	root := &testNode{Name: "root"}
+	root.Parent = root
+	var out *testNode
+	for i := 0; i < 2; i++ {
+		err = To(root, &out)
+		require.Nil(t, err)
+		assert.Same(t, out, out.Parent)
+	}
+	kept := out
+
+	// into a cycle of existing pointers
+	other := &testNode{Name: "other", Parent: out}
+	out.Parent = other
+	err = To(root, &out)
+	require.Nil(t, err)
+	assert.Same(t, kept, out)
+	assert.Equal(t, "root", out.Parent.Name)
+}
+
+func TestWithMaxDepth(t *testing.T) {
+	src := map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}}}
+
+	var dst map[string]map[string]map[string]int
+	err := NewConverter(WithMaxDepth(3)).To(src, &dst)
+	assert.Equal(t, &MaxDepthError{3}, err)
+	assert.Equal(t, "exceeded max depth 3", err.Error())
+
+	err = NewConverter(WithMaxDepth(4)).To(src, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, 1, dst["a"]["b"]["c"])
+
+	_, err = NewConverter(WithMaxDepth(2)).ToMap(src)
+	assert.Equal(t, &MaxDepthError{2}, err)
+
+	err = NewConverter(WithMaxDepth(0)).To(src, &dst)
+	require.Nil(t, err)
+}
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+func (l testLevel) MarshalText() ([]byte, error) {
+	return []byte([]string{"debug", "info"}[l]), nil
+}
+
+type testList []string
+
+func (l *testList) Set(s string) error {
+	*l = strings.Split(s, ",")
+	return nil
+}
+
+func (l *testList) String() string {
+	return strings.Join(*l, ",")
+}
+
+type testPoint struct {
+	X, Y int
+}
+
+func (p *testPoint) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	_, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+func TestUnmarshalers(t *testing.T) {
+	var dst struct {
+		Level  testLevel
+		PLevel *testLevel
+		List   testList
+		Point  testPoint
+		Big    big.Int
+	}
+	src := map[string]interface{}{
+		"level":  "info",
+		"plevel": "info",
+		"list":   "a,b",
+		"point":  "1,2",
+		"big":    "123456789012345678901234567890",
+	}
+	for _, weak := range []bool{false, true} {
+		err := NewConverter(WithWeak(weak)).To(src, &dst)
+		require.Nil(t, err)
+		assert.Equal(t, testLevel(1), dst.Level)
+		assert.Equal(t, testLevel(1), *dst.PLevel)
+		assert.Equal(t, testList{"a", "b"}, dst.List)
+		assert.Equal(t, testPoint{1, 2}, dst.Point)
+		assert.Equal(t, "123456789012345678901234567890", dst.Big.String())
+	}
+
+	err := To(map[string]interface{}{"level": "x"}, &dst)
+	assert.Equal(t, `level: unknown level "x"`, err.Error())
+
+	// non-string sources are converted by kind
+	err = To(map[string]interface{}{"level": 0}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, testLevel(0), dst.Level)
+
+	m, err := ToMap(struct {
+		Level  testLevel
+		Levels map[testLevel]int
+	}{1, map[testLevel]int{1: 2}})
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Level":  "info",
+		"Levels": map[string]interface{}{"info": 2},
+	}, m)
+
+	_, err = ToMap(big.NewInt(1))
+	assert.NotNil(t, err)
+
+	// strings round-trip like ToMap
+	str, err := WeakToString(testLevel(1))
+	require.Nil(t, err)
+	assert.Equal(t, "info", str)
+	var lvl testLevel
+	err = To(str, &lvl)
+	require.Nil(t, err)
+	assert.Equal(t, testLevel(1), lvl)
+
+	err = To(&lvl, &str)
+	require.Nil(t, err)
+	assert.Equal(t, "info", str)
+
+	var keys map[string]int
+	err = WeakTo(map[testLevel]int{0: 1}, &keys)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]int{"debug": 1}, keys)
+}
+
 func TestWeakToInt(t *testing.T) {
 	x := 1
 	succTests := []struct {
@@ -878,6 +1537,914 @@ func TestWeakToComplex(t *testing.T) {
 	}
 }
 
+type testCelsius float64
+
+func TestRegister(t *testing.T) {
+	typ := reflect.TypeOf(testCelsius(0))
+	Register(typ, func(src, dst reflect.Value) error {
+		s := indirect(src)
+		if s.Kind() != reflect.String {
+			return &CannotConvError{s.Kind(), dst.Kind()}
+		}
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s.String(), "C"), 64)
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+		return nil
+	})
+	defer Register(typ, nil)
+
+	var dst struct{ Temp testCelsius }
+	err := To(map[string]interface{}{"temp": "21.5C"}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, testCelsius(21.5), dst.Temp)
+
+	// weak converters are registered separately
+	var weak testCelsius
+	err = WeakTo("21.5C", &weak)
+	assert.NotNil(t, err)
+
+	// built-in converters can be overridden
+	durType := reflect.TypeOf(time.Duration(0))
+	Register(durType, func(src, dst reflect.Value) error {
+		dst.SetInt(int64(time.Second) * indirect(src).Int())
+		return nil
+	})
+	defer Register(durType, toTimeDuration)
+
+	var dur time.Duration
+	err = To(2, &dur)
+	require.Nil(t, err)
+	assert.Equal(t, 2*time.Second, dur)
+}
+
+func TestConverter(t *testing.T) {
+	c := NewConverter(
+		WithWeak(true),
+		WithTimeLayouts(time.RFC3339, "2006-01-02"),
+		WithTagName("yaml"),
+		WithKeyMatcher(func(key string, field reflect.StructField) bool {
+			return strings.EqualFold(key, field.Name)
+		}),
+		WithHooks(func(src reflect.Value, typ reflect.Type) (reflect.Value, error) {
+			if typ.Kind() == reflect.String && indirect(src).Kind() == reflect.Bool {
+				return reflect.ValueOf("on"), nil
+			}
+			return src, nil
+		}),
+	)
+
+	var dst struct {
+		Port    int       `yaml:"listen_port"`
+		Since   time.Time `yaml:"since"`
+		Enabled string
+	}
+	src := map[string]interface{}{
+		"LISTEN_PORT": "8080",
+		"since":       "2019-11-01",
+		"enabled":     true,
+	}
+	err := c.To(src, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, 8080, dst.Port)
+	assert.Equal(t, time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC), dst.Since)
+	assert.Equal(t, "on", dst.Enabled)
+
+	// strict by default
+	var port int
+	err = NewConverter().To("8080", &port)
+	assert.NotNil(t, err)
+}
+
+func TestWithConvFunc(t *testing.T) {
+	typ := reflect.TypeOf(testCelsius(0))
+	parse := func(unit string) ConvFunc {
+		return func(src, dst reflect.Value) error {
+			f, err := strconv.ParseFloat(strings.TrimSuffix(indirect(src).String(), unit), 64)
+			if err != nil {
+				return err
+			}
+			dst.SetFloat(f)
+			return nil
+		}
+	}
+	c := NewConverter(WithConvFunc(typ, parse("C")), WithWeakConvFunc(typ, parse("°C")))
+
+	var temp testCelsius
+	err := c.To("21.5C", &temp)
+	require.Nil(t, err)
+	assert.Equal(t, testCelsius(21.5), temp)
+
+	err = NewConverter(WithWeak(true), WithWeakConvFunc(typ, parse("°C"))).To("20°C", &temp)
+	require.Nil(t, err)
+	assert.Equal(t, testCelsius(20), temp)
+
+	// not registered globally
+	err = To("21.5C", &temp)
+	assert.NotNil(t, err)
+
+	// a Converter doesn't read the deprecated TimeLayout
+	old := TimeLayout
+	TimeLayout = time.RFC3339
+	defer func() { TimeLayout = old }()
+
+	var tm time.Time
+	err = NewConverter().To("2021-01-02T03:04:05Z", &tm)
+	assert.NotNil(t, err)
+	err = NewConverter().To(time.Unix(0, 0).UTC().Format(DefaultTimeLayout), &tm)
+	require.Nil(t, err)
+	err = To("2021-01-02T03:04:05Z", &tm)
+	require.Nil(t, err)
+	assert.Equal(t, 2021, tm.Year())
+}
+
+func TestConverter_concurrent(t *testing.T) {
+	c := NewConverter(WithWeak(true), WithTimeLayouts("2006-01-02"))
+	done := make(chan error)
+	for i := 0; i < 8; i++ {
+		go func() {
+			var dst struct {
+				N int
+				T time.Time
+			}
+			done <- c.To(map[string]interface{}{"n": "1", "t": "2019-11-01"}, &dst)
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		require.Nil(t, <-done)
+	}
+}
+
+func TestKeyMatchers(t *testing.T) {
+	field := func(name string) reflect.StructField {
+		return reflect.StructField{Name: name}
+	}
+
+	tests := []struct {
+		matcher KeyMatcher
+		key     string
+		field   string
+		want    bool
+	}{
+		{DefaultKeyMatcher, "maxconns", "MaxConns", true},
+		{DefaultKeyMatcher, "MaxConns", "MaxConns", true},
+		{DefaultKeyMatcher, "Maxconns", "MaxConns", false},
+		{ExactKeyMatcher, "MaxConns", "MaxConns", true},
+		{ExactKeyMatcher, "maxconns", "MaxConns", false},
+		{CaseInsensitiveKeyMatcher, "MAXCONNS", "MaxConns", true},
+		{SnakeCaseKeyMatcher, "max_conns", "MaxConns", true},
+		{SnakeCaseKeyMatcher, "http_server_url", "HTTPServerURL", true},
+		{SnakeCaseKeyMatcher, "port2_v3", "Port2V3", true},
+		{SnakeCaseKeyMatcher, "max_conns", "max_conns", true},
+		{SnakeCaseKeyMatcher, "maxconns", "MaxConns", false},
+		{KebabCaseKeyMatcher, "max-conns", "MaxConns", true},
+		{KebabCaseKeyMatcher, "max_conns", "MaxConns", false},
+		{CamelCaseKeyMatcher, "maxConns", "MaxConns", true},
+		{CamelCaseKeyMatcher, "httpPort", "HTTPPort", true},
+		{CamelCaseKeyMatcher, "MaxConns", "MaxConns", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, test.matcher(test.key, field(test.field)), "%s %s", test.key, test.field)
+	}
+
+	c := NewConverter(WithKeyMatcher(AnyKeyMatcher(CaseInsensitiveKeyMatcher, SnakeCaseKeyMatcher, KebabCaseKeyMatcher)))
+	for _, key := range []string{"max_conns", "max-conns", "MaxConns", "maxconns"} {
+		var dst struct {
+			MaxConns int
+		}
+		err := c.To(map[string]interface{}{key: 10}, &dst)
+		require.Nil(t, err)
+		assert.Equal(t, 10, dst.MaxConns, key)
+	}
+}
+
+func TestToT(t *testing.T) {
+	dur, err := ToT[time.Duration]("2s")
+	require.Nil(t, err)
+	assert.Equal(t, 2*time.Second, dur)
+
+	i, err := ToT[int]("1")
+	assert.NotNil(t, err)
+	assert.Zero(t, i)
+
+	ints, err := WeakToT[[]int]([]interface{}{"1", 2, 3.0})
+	require.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, ints)
+
+	assert.Equal(t, int64(1), MustToT[int64](int32(1)))
+	assert.Equal(t, "1", MustWeakToT[string](1))
+	assert.Panics(t, func() { MustToT[bool]("true") })
+	assert.Panics(t, func() { MustWeakToT[bool]("yes") })
+}
+
+func TestToXXX(t *testing.T) {
+	m := map[string]interface{}{
+		"port":    "8080",
+		"timeout": "30s",
+		"debug":   1,
+		"size":    "10MB",
+		"ip":      "8.8.8.8",
+	}
+
+	port, err := WeakToInt64(m["port"])
+	require.Nil(t, err)
+	assert.Equal(t, int64(8080), port)
+
+	_, err = ToInt64(m["port"])
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(80), ToInt64Or(m["port"], 80))
+	assert.Equal(t, int64(8080), WeakToInt64Or(m["port"], 80))
+
+	assert.Equal(t, 30*time.Second, ToDurationOr(m["timeout"], time.Second))
+	assert.Equal(t, true, WeakToBoolOr(m["debug"], false))
+	assert.Equal(t, false, ToBoolOr(m["debug"], false))
+	assert.Equal(t, ByteSize(10<<20), ToByteSizeOr(m["size"], 0))
+	assert.Equal(t, "8.8.8.8", ToIPOr(m["ip"], nil).String())
+	assert.Equal(t, "1", WeakToStringOr(m["debug"], ""))
+	assert.Equal(t, uint(7), WeakToUintOr("-1", 7))
+	assert.Equal(t, 1.5, WeakToFloat64Or("1.5", 0))
+
+	// missing keys
+	assert.Equal(t, "def", ToStringOr(m["missing"], "def"))
+	assert.Equal(t, "def", WeakToStringOr(nil, "def"))
+	assert.Equal(t, 30*time.Second, ToDurationOr(m["missing"], 30*time.Second))
+	assert.Equal(t, true, ToBoolOr(m["missing"], true))
+	assert.Equal(t, int64(80), WeakToInt64Or((*int)(nil), 80))
+	assert.Equal(t, "127.0.0.1", ToIPOr(m["missing"], net.IPv4(127, 0, 0, 1)).String())
+	assert.Equal(t, "testStringer.String", ToStringOr(testStringer{}, "def"))
+}
+
+type testServer struct {
+	Host    string
+	IP      net.IP
+	Timeout time.Duration
+}
+
+type testConfig struct {
+	Name    string
+	Size    ByteSize
+	Since   time.Time
+	URL     *url.URL
+	Servers []testServer
+	Labels  map[string]int
+	Main    testServer
+	hidden  int
+}
+
+func TestToMap(t *testing.T) {
+	u, _ := url.Parse("http://host/path?param=x")
+	since := time.Date(2019, 11, 1, 19, 13, 55, 0, time.UTC)
+	src := testConfig{
+		Name:  "name",
+		Size:  ByteSize(100 << 20),
+		Since: since,
+		URL:   u,
+		Servers: []testServer{
+			{"a", net.ParseIP("8.8.8.8"), time.Second},
+		},
+		Labels: map[string]int{"x": 1},
+		Main:   testServer{"main", net.ParseIP("::1"), 100 * time.Millisecond},
+		hidden: 1,
+	}
+
+	m, err := ToMap(&src)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Name":  "name",
+		"Size":  "100MB",
+		"Since": since.Format(TimeLayout),
+		"URL":   "http://host/path?param=x",
+		"Servers": []interface{}{
+			map[string]interface{}{"Host": "a", "IP": "8.8.8.8", "Timeout": "1s"},
+		},
+		"Labels": map[string]interface{}{"x": 1},
+		"Main":   map[string]interface{}{"Host": "main", "IP": "::1", "Timeout": "100ms"},
+	}, m)
+
+	var dst testConfig
+	err = To(m, &dst)
+	require.Nil(t, err)
+	src.hidden = 0
+	assert.Equal(t, src, dst)
+
+	m, err = ToMap(struct {
+		IP     net.IP
+		Labels map[string]int
+	}{})
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"IP": nil, "Labels": nil}, m)
+
+	_, err = ToMap(1)
+	assert.NotNil(t, err)
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	typ := reflect.TypeOf(testCelsius(0))
+	Register(typ, func(src, dst reflect.Value) error {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(indirect(src).String(), "C"), 64)
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+		return nil
+	})
+	defer Register(typ, nil)
+	RegisterEncoder(typ, func(v reflect.Value) (interface{}, error) {
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64) + "C", nil
+	})
+	defer RegisterEncoder(typ, nil)
+
+	type room struct {
+		Temp  testCelsius
+		Temps []testCelsius
+	}
+	src := room{Temp: 21.5, Temps: []testCelsius{20, 22}}
+	m, err := ToMap(src)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Temp":  "21.5C",
+		"Temps": []interface{}{"20C", "22C"},
+	}, m)
+
+	var dst room
+	err = To(m, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, src, dst)
+
+	// per Converter
+	c := NewConverter(WithEncodeFunc(typ, func(v reflect.Value) (interface{}, error) {
+		return v.Float() * 10, nil
+	}))
+	m, err = c.ToMap(room{Temp: 2})
+	require.Nil(t, err)
+	assert.Equal(t, 20.0, m["Temp"])
+}
+
+type testBase struct {
+	ID   int    `conv:"id"`
+	Kind string `conv:"kind,omitempty"`
+}
+
+type testTagged struct {
+	testBase
+	Name   string `conv:"name"`
+	Port   int    `conv:"listen_port,omitempty"`
+	Secret string `conv:"-"`
+	Plain  int
+}
+
+func TestStructTag(t *testing.T) {
+	src := map[string]interface{}{
+		"id":          1,
+		"name":        "x",
+		"listen_port": 80,
+		"secret":      "s",
+		"Secret":      "s",
+		"plain":       2,
+	}
+
+	var dst testTagged
+	err := To(src, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, testTagged{testBase: testBase{ID: 1}, Name: "x", Port: 80, Plain: 2}, dst)
+
+	// struct to struct by key
+	var other struct {
+		ID     int    `conv:"id"`
+		Host   string `conv:"name"`
+		Secret string
+	}
+	dst.Secret = "s"
+	err = To(dst, &other)
+	require.Nil(t, err)
+	assert.Equal(t, 1, other.ID)
+	assert.Equal(t, "x", other.Host)
+	assert.Equal(t, "", other.Secret)
+
+	// encode
+	m, err := ToMap(testTagged{testBase: testBase{ID: 1}, Name: "x", Secret: "s"})
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"id": 1, "name": "x", "Plain": 0}, m)
+
+	var mi map[string]interface{}
+	err = To(testTagged{testBase: testBase{ID: 1}, Port: 80}, &mi)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"id": 1, "name": "", "listen_port": 80, "Plain": 0}, mi)
+}
+
+type testMeta struct {
+	ID      int
+	Created string
+}
+
+type testAudit struct {
+	ID      int
+	Updated string
+}
+
+type TestExportedBase struct {
+	Kind string
+}
+
+type testRequiredBase struct {
+	Kind string `conv:"kind,required"`
+}
+
+type testEmbedded struct {
+	*TestExportedBase
+	testMeta
+	testAudit
+	Named testBase `conv:"named"`
+	Name  string
+}
+
+func TestEmbedded(t *testing.T) {
+	// squashed by default, embedded pointers allocated only when set
+	var dst testEmbedded
+	err := To(map[string]interface{}{"name": "x", "created": "c"}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, testEmbedded{testMeta: testMeta{Created: "c"}, Name: "x"}, dst)
+
+	err = To(map[string]interface{}{"kind": "k"}, &dst)
+	require.Nil(t, err)
+	require.NotNil(t, dst.TestExportedBase)
+	assert.Equal(t, "k", dst.Kind)
+
+	// named embedded structs aren't squashed
+	err = To(map[string]interface{}{"named": map[string]interface{}{"id": 1}}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, 1, dst.Named.ID)
+
+	// conflicting promoted fields
+	err = To(map[string]interface{}{"id": 1}, &dst)
+	require.NotNil(t, err)
+	assert.Equal(t, `id: key "id" matches conflicting fields testMeta.ID, testAudit.ID of conv.testEmbedded`, err.Error())
+	var ce *FieldConflictError
+	require.True(t, errors.As(err, &ce))
+	assert.Equal(t, []string{"testMeta.ID", "testAudit.ID"}, ce.Fields)
+
+	// unexported embedded pointers can't be allocated
+	var unexported struct {
+		*testMeta
+	}
+	err = To(map[string]interface{}{"created": "c"}, &unexported)
+	assert.NotNil(t, err)
+	assert.Nil(t, unexported.testMeta)
+
+	// embedded pointers stay nil if their fields fail
+	var failed struct {
+		*TestExportedBase
+		Name string
+	}
+	err = To(map[string]interface{}{"kind": 1, "name": "x"}, &failed)
+	assert.NotNil(t, err)
+	assert.Nil(t, failed.TestExportedBase)
+
+	var required struct {
+		*testRequiredBase
+		Name string
+	}
+	err = To(map[string]interface{}{"name": "x"}, &required)
+	assert.True(t, errors.Is(err, ErrRequired))
+	assert.Nil(t, required.testRequiredBase)
+
+	// unset embedded pointers are skipped in the source
+	var other testEmbedded
+	err = To(testEmbedded{Name: "x"}, &other)
+	require.Nil(t, err)
+	assert.Equal(t, testEmbedded{Name: "x"}, other)
+
+	m, err := ToMap(testEmbedded{TestExportedBase: &TestExportedBase{Kind: "k"}, Name: "x"})
+	require.Nil(t, err)
+	assert.Equal(t, "k", m["Kind"])
+	assert.NotContains(t, m, "TestExportedBase")
+
+	// embedded structs with a registered converter are fields
+	var ts struct {
+		time.Time
+		Name string
+	}
+	err = NewConverter(WithTimeLayouts(time.RFC3339)).To(map[string]interface{}{"time": "2021-01-02T03:04:05Z"}, &ts)
+	require.Nil(t, err)
+	assert.Equal(t, 2021, ts.Year())
+}
+
+type testUnexported struct {
+	Name   string
+	secret string
+}
+
+func TestUnexportedFields(t *testing.T) {
+	dst := testUnexported{secret: "s"}
+	err := To(map[string]interface{}{"name": "x", "secret": "y"}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, testUnexported{Name: "x", secret: "s"}, dst)
+
+	var other testUnexported
+	err = To(testUnexported{Name: "x", secret: "y"}, &other)
+	require.Nil(t, err)
+	assert.Equal(t, testUnexported{Name: "x"}, other)
+
+	// unexported source fields are never keys
+	var exported struct{ Secret int }
+	err = To(struct{ secret int }{42}, &exported)
+	require.Nil(t, err)
+	assert.Equal(t, 0, exported.Secret)
+
+	meta, err := ToWithMetadata(map[string]interface{}{}, &other)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"Name"}, meta.Unset)
+
+	var unexported struct {
+		*testMeta
+	}
+	err = To(map[string]interface{}{"created": "c"}, &unexported)
+	var se *CannotSetError
+	require.True(t, errors.As(err, &se))
+	assert.Equal(t, "testMeta", se.Field)
+	assert.Equal(t, reflect.TypeOf(&testMeta{}), se.Type)
+	assert.Equal(t, "created: cannot set field testMeta of type *conv.testMeta", err.Error())
+
+	// positional and scalar sources skip unexported fields
+	var positional struct {
+		a int
+		B int
+	}
+	err = To([]int{1, 2}, &positional)
+	require.Nil(t, err)
+	assert.Equal(t, 0, positional.a)
+	assert.Equal(t, 2, positional.B)
+
+	err = To(3, &positional)
+	require.Nil(t, err)
+	assert.Equal(t, 0, positional.a)
+	assert.Equal(t, 3, positional.B)
+
+	// unexported source fields are skipped
+	var iface struct{ X interface{} }
+	err = To(struct{ x interface{} }{1}, &iface)
+	require.Nil(t, err)
+	assert.Nil(t, iface.X)
+
+	var m map[string]interface{}
+	err = To(struct {
+		a int
+		B int
+	}{1, 2}, &m)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"B": 2}, m)
+
+	var s []interface{}
+	err = To(struct {
+		a int
+		B int
+	}{1, 2}, &s)
+	require.Nil(t, err)
+	assert.Equal(t, []interface{}{2}, s)
+
+	var arr [2]interface{}
+	err = NewConverter(WithArrayLenPolicy(ArrayStrict)).To(struct {
+		a    interface{}
+		B, C int
+	}{1, 2, 3}, &arr)
+	require.Nil(t, err)
+	assert.Equal(t, [2]interface{}{2, 3}, arr)
+
+	var items struct{ Items string }
+	err = WeakTo(struct{ items []int }{[]int{1}}, &items)
+	require.Nil(t, err)
+	assert.Equal(t, "", items.Items)
+
+	var small struct{ N int8 }
+	err = WeakTo(struct{ n int }{1000}, &small)
+	require.Nil(t, err)
+	assert.Equal(t, int8(0), small.N)
+
+	var tm time.Time
+	err = To(1, &tm)
+	var ce *CannotConvError
+	require.True(t, errors.As(err, &ce))
+	assert.True(t, tm.IsZero())
+}
+
+type testKey int
+
+func (k testKey) String() string {
+	return fmt.Sprintf("key%d", int(k))
+}
+
+func TestToStruct_nonStringKeys(t *testing.T) {
+	src := map[interface{}]interface{}{
+		"name": "x",
+		"tls": map[interface{}]interface{}{
+			"cert": "a.pem",
+		},
+		1:          "one",
+		testKey(2): 2,
+	}
+	var dst struct {
+		Name string
+		TLS  struct {
+			Cert string
+		}
+		One  string `conv:"1"`
+		Key2 int
+	}
+	err := To(src, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, "x", dst.Name)
+	assert.Equal(t, "a.pem", dst.TLS.Cert)
+	assert.Equal(t, "one", dst.One)
+	assert.Equal(t, 2, dst.Key2)
+
+	var m map[string]interface{}
+	err = To(map[interface{}]interface{}{"a": 1}, &m)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, m)
+
+	c := NewConverter(WithErrorUnknownKeys(true))
+	err = c.To(map[interface{}]interface{}{3: 1, nil: 2}, &dst)
+	var ue *UnknownKeysError
+	require.True(t, errors.As(err, &ue))
+	assert.Equal(t, []string{"", "3"}, ue.Paths)
+}
+
+func TestFieldError(t *testing.T) {
+	src := map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"tls": map[string]interface{}{"cert": "a.pem"}},
+			map[string]interface{}{"tls": map[string]interface{}{"cert": 1}},
+		},
+	}
+	var dst struct {
+		Servers []struct {
+			TLS *struct {
+				Cert string
+			}
+		}
+	}
+
+	err := To(src, &dst)
+	require.NotNil(t, err)
+	assert.Equal(t, "servers[1].tls.cert: cannot convert int to string", err.Error())
+
+	var fe *FieldError
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, "servers[1].tls.cert", fe.Path)
+	assert.Equal(t, 1, fe.Src)
+	assert.Equal(t, reflect.TypeOf(0), fe.SrcType)
+	assert.Equal(t, reflect.TypeOf(""), fe.DstType)
+
+	var ce *CannotConvError
+	assert.True(t, errors.As(err, &ce))
+
+	var m map[int][]int
+	err = WeakTo(map[int]interface{}{3: []string{"1", "x"}}, &m)
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, "[3][1]", fe.Path)
+}
+
+func TestWithAllErrors(t *testing.T) {
+	src := map[string]interface{}{
+		"port":    "x",
+		"timeout": "1s",
+		"servers": []interface{}{
+			map[string]interface{}{"port": 1},
+			map[string]interface{}{"port": "y"},
+			map[string]interface{}{"port": "z"},
+		},
+	}
+	var dst struct {
+		Port    int
+		Timeout time.Duration
+		Servers []struct {
+			Port int
+		}
+	}
+
+	c := NewConverter(WithWeak(true), WithAllErrors(true))
+	err := c.To(src, &dst)
+
+	var me *MultiError
+	require.True(t, errors.As(err, &me))
+	paths := make([]string, 0, len(me.Errors))
+	for _, err := range me.Errors {
+		var fe *FieldError
+		require.True(t, errors.As(err, &fe))
+		paths = append(paths, fe.Path)
+	}
+	assert.ElementsMatch(t, []string{"port", "servers[1].port", "servers[2].port"}, paths)
+	assert.Equal(t, time.Second, dst.Timeout)
+	assert.Equal(t, 1, dst.Servers[0].Port)
+
+	var ne *strconv.NumError
+	assert.True(t, errors.As(err, &ne))
+
+	// stops on the first error by default
+	err = WeakTo(src, &dst)
+	assert.False(t, errors.As(err, &me))
+}
+
+func TestWithErrorUnknownKeys(t *testing.T) {
+	src := map[string]interface{}{
+		"timout": "1s",
+		"name":   "x",
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a", "prot": 1},
+			map[string]interface{}{"host": "b"},
+		},
+	}
+	type config struct {
+		Timeout time.Duration
+		Name    string
+		Servers []struct {
+			Host string
+			Port int
+		}
+	}
+
+	var dst config
+	err := NewConverter(WithErrorUnknownKeys(true)).To(src, &dst)
+	var ue *UnknownKeysError
+	require.True(t, errors.As(err, &ue))
+	assert.ElementsMatch(t, []string{"timout", "servers[0].prot"}, ue.Paths)
+	assert.Equal(t, "x", dst.Name)
+	assert.Equal(t, "b", dst.Servers[1].Host)
+
+	src["servers"].([]interface{})[1].(map[string]interface{})["port"] = "x"
+	err = NewConverter(WithWeak(true), WithErrorUnknownKeys(true), WithAllErrors(true)).To(src, &dst)
+	var me *MultiError
+	require.True(t, errors.As(err, &me))
+	require.True(t, errors.As(err, &ue))
+	assert.ElementsMatch(t, []string{"timout", "servers[0].prot"}, ue.Paths)
+	assert.Len(t, me.Errors, 2)
+
+	// the elements of maps and pointers are kept like the ones of slices
+	strict := NewConverter(WithErrorUnknownKeys(true))
+	var m map[string]struct{ A int }
+	err = strict.To(map[string]interface{}{"x": map[string]interface{}{"a": 1, "b": 2}}, &m)
+	require.True(t, errors.As(err, &ue))
+	assert.Equal(t, []string{"x.b"}, ue.Paths)
+	assert.Equal(t, map[string]struct{ A int }{"x": {A: 1}}, m)
+
+	var ptr struct{ P *struct{ A int } }
+	err = strict.To(map[string]interface{}{"p": map[string]interface{}{"a": 1, "b": 2}}, &ptr)
+	require.True(t, errors.As(err, &ue))
+	assert.Equal(t, []string{"p.b"}, ue.Paths)
+	require.NotNil(t, ptr.P)
+	assert.Equal(t, 1, ptr.P.A)
+
+	// ignored by default
+	delete(src, "servers")
+	err = To(src, &dst)
+	assert.Nil(t, err)
+}
+
+func TestToWithMetadata(t *testing.T) {
+	src := map[string]interface{}{
+		"name":    "x",
+		"timout":  "1s",
+		"servers": []interface{}{map[string]interface{}{"host": "a", "prot": 1}},
+	}
+	var dst struct {
+		Name    string
+		Timeout time.Duration
+		Servers []struct {
+			Host string
+			Port int
+		}
+	}
+
+	meta, err := ToWithMetadata(src, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"name", "servers", "servers[0].host"}, meta.Keys)
+	assert.Equal(t, []string{"servers[0].prot", "timout"}, meta.Unused)
+	assert.Equal(t, []string{"Timeout", "servers[0].Port"}, meta.Unset)
+	assert.Equal(t, "a", dst.Servers[0].Host)
+
+	meta, err = WeakToWithMetadata(map[string]interface{}{"timeout": 1}, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"timeout"}, meta.Keys)
+	assert.Equal(t, []string{"Name", "Servers"}, meta.Unset)
+
+	// struct to struct
+	type server struct {
+		Host  string
+		Proto string
+	}
+	srcStruct := struct {
+		Name    string
+		Servers []server
+		Extra   int
+	}{Name: "x", Servers: []server{{Host: "a"}}}
+	meta, err = ToWithMetadata(srcStruct, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"Name", "Servers", "Servers[0].Host"}, meta.Keys)
+	assert.Equal(t, []string{"Extra", "Servers[0].Proto"}, meta.Unused)
+	assert.Equal(t, []string{"Servers[0].Port", "Timeout"}, meta.Unset)
+}
+
+func TestRequiredAndDefault(t *testing.T) {
+	type server struct {
+		Host    string        `conv:"host,required"`
+		Timeout time.Duration `conv:"timeout,default=30s"`
+		Size    ByteSize      `conv:"size,default=10MB"`
+		Addr    string        `conv:"addr,default=:80,:443"`
+	}
+	var dst struct {
+		Servers []server
+	}
+
+	src := map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a", "timeout": "1s"},
+		},
+	}
+	err := To(src, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, server{"a", time.Second, 10 << 20, ":80,:443"}, dst.Servers[0])
+
+	src = map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a"},
+			map[string]interface{}{"size": "1KB"},
+		},
+	}
+	dst.Servers = nil
+	err = To(src, &dst)
+	var fe *FieldError
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, "servers[1].host", fe.Path)
+	assert.True(t, errors.Is(err, ErrRequired))
+
+	// null values are missing
+	src = map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a", "timeout": nil, "size": (*string)(nil)},
+		},
+	}
+	dst.Servers = nil
+	err = NewConverter(WithNullPolicy(NullError)).To(src, &dst)
+	require.Nil(t, err)
+	assert.Equal(t, server{"a", 30 * time.Second, 10 << 20, ":80,:443"}, dst.Servers[0])
+
+	err = To(map[string]interface{}{"servers": []interface{}{map[string]interface{}{"host": nil}}}, &dst)
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, "servers[0].host", fe.Path)
+	assert.True(t, errors.Is(err, ErrRequired))
+
+	// missing sections
+	type db struct {
+		Host    string        `conv:"host,required"`
+		Timeout time.Duration `conv:"timeout,default=30s"`
+	}
+	var cfg struct {
+		DB  db
+		Log struct {
+			Level string `conv:"level,default=info"`
+		}
+	}
+	err = To(map[string]interface{}{}, &cfg)
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, "DB.host", fe.Path)
+	assert.True(t, errors.Is(err, ErrRequired))
+
+	err = NewConverter(WithAllErrors(true)).To(map[string]interface{}{}, &cfg)
+	var me *MultiError
+	require.True(t, errors.As(err, &me))
+	assert.Len(t, me.Errors, 1)
+	assert.Equal(t, 30*time.Second, cfg.DB.Timeout)
+	assert.Equal(t, "info", cfg.Log.Level)
+
+	err = To(map[string]interface{}{"db": map[string]interface{}{"host": "h"}}, &cfg)
+	require.Nil(t, err)
+	assert.Equal(t, db{"h", 30 * time.Second}, cfg.DB)
+
+	// struct sources
+	var fromStruct struct{ DB db }
+	err = To(struct{ Name string }{"x"}, &fromStruct)
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, "DB.host", fe.Path)
+
+	err = To(struct {
+		DB struct {
+			Host string `conv:"host"`
+		}
+	}{}, &fromStruct)
+	require.Nil(t, err)
+	assert.Equal(t, db{"", 30 * time.Second}, fromStruct.DB)
+
+	// invalid defaults fail like the source values
+	var bad struct {
+		N int `conv:"n,default=x"`
+	}
+	err = To(map[string]interface{}{}, &bad)
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, "n", fe.Path)
+	assert.Equal(t, "x", fe.Src)
+}
+
 func BenchmarkToBool(b *testing.B) {
 	var src, dst bool
 	for i := 0; i < b.N; i++ {
@@ -0,0 +1,326 @@
+package conv
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Converter converts values from one type to another according to its options.
+// A Converter is safe for concurrent use by multiple goroutines.
+type Converter struct {
+	timeLayouts []string
+	weak        bool
+	tagName     string
+	keyMatcher  KeyMatcher
+	hooks       []Hook
+	allErrors   bool
+
+	errorUnknownKeys bool
+	arrayLenPolicy   ArrayLenPolicy
+	sliceMergeMode   SliceMergeMode
+	mapMergeMode     MapMergeMode
+	nullPolicy       NullPolicy
+	deepCopy         bool
+	maxDepth         int
+
+	convs     map[reflect.Type]convFunc   // registered by WithConvFunc
+	weakConvs map[reflect.Type]convFunc   // registered by WithWeakConvFunc
+	encoders  map[reflect.Type]encodeFunc // registered by WithEncodeFunc
+
+	globalTimeLayout bool // use the deprecated TimeLayout
+
+	fieldCache sync.Map // map[reflect.Type]*structInfo
+}
+
+// Option configures a Converter.
+type Option func(*Converter)
+
+// KeyMatcher reports whether the map key selects the struct field.
+// field.Name is the name given by the struct tag if any.
+type KeyMatcher func(key string, field reflect.StructField) bool
+
+// Hook is called before converting src to a value of type typ.
+// The returned value is converted instead of src.
+type Hook func(src reflect.Value, typ reflect.Type) (reflect.Value, error)
+
+// NewConverter returns a Converter configured by opts.
+func NewConverter(opts ...Option) *Converter {
+	c := &Converter{
+		tagName:    "conv",
+		keyMatcher: DefaultKeyMatcher,
+		maxDepth:   DefaultMaxDepth,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithTimeLayouts sets the layouts tried in order when parsing a time.Time from a string,
+// the first one formats it. Without it, DefaultTimeLayout is used.
+func WithTimeLayouts(layouts ...string) Option {
+	return func(c *Converter) {
+		c.timeLayouts = append([]string(nil), layouts...)
+		c.globalTimeLayout = false
+	}
+}
+
+// WithConvFunc sets fn as the converter used by To for dst of type typ,
+// taking precedence over the ones registered with Register.
+// A nil fn falls back to them.
+func WithConvFunc(typ reflect.Type, fn ConvFunc) Option {
+	return func(c *Converter) {
+		c.convs = withConv(c.convs, typ, bind(fn))
+	}
+}
+
+// WithWeakConvFunc is like WithConvFunc for weak type convert.
+func WithWeakConvFunc(typ reflect.Type, fn ConvFunc) Option {
+	return func(c *Converter) {
+		c.weakConvs = withConv(c.weakConvs, typ, bind(fn))
+	}
+}
+
+// WithEncodeFunc sets fn as the encoder used by ToMap for values of type typ,
+// taking precedence over the ones registered with RegisterEncoder.
+// A nil fn falls back to them.
+func WithEncodeFunc(typ reflect.Type, fn EncodeFunc) Option {
+	return func(c *Converter) {
+		if typ == nil {
+			panic("conv: WithEncodeFunc of nil type")
+		}
+		if c.encoders == nil {
+			c.encoders = make(map[reflect.Type]encodeFunc)
+		}
+		if fn == nil {
+			delete(c.encoders, typ)
+		} else {
+			c.encoders[typ] = bindEncoder(fn)
+		}
+	}
+}
+
+func withConv(convs map[reflect.Type]convFunc, typ reflect.Type, fn convFunc) map[reflect.Type]convFunc {
+	if typ == nil {
+		panic("conv: WithConvFunc of nil type")
+	}
+	if convs == nil {
+		convs = make(map[reflect.Type]convFunc)
+	}
+	if fn == nil {
+		delete(convs, typ)
+	} else {
+		convs[typ] = fn
+	}
+	return convs
+}
+
+// WithWeak sets whether To does a weak type convert like WeakTo.
+func WithWeak(weak bool) Option {
+	return func(c *Converter) {
+		c.weak = weak
+	}
+}
+
+// WithTagName sets the struct tag key used to name fields, "conv" by default.
+func WithTagName(name string) Option {
+	return func(c *Converter) {
+		c.tagName = name
+	}
+}
+
+// WithKeyMatcher sets how map keys are matched to struct fields,
+// DefaultKeyMatcher by default.
+func WithKeyMatcher(m KeyMatcher) Option {
+	return func(c *Converter) {
+		c.keyMatcher = m
+	}
+}
+
+// WithHooks appends hooks run in order before every conversion.
+func WithHooks(hooks ...Hook) Option {
+	return func(c *Converter) {
+		c.hooks = append(c.hooks[:len(c.hooks):len(c.hooks)], hooks...)
+	}
+}
+
+// WithAllErrors sets whether to keep converting after an element of a composite
+// value fails, and return a MultiError of all failed paths.
+func WithAllErrors(all bool) Option {
+	return func(c *Converter) {
+		c.allErrors = all
+	}
+}
+
+// WithErrorUnknownKeys sets whether converting a map to a struct fails with
+// an UnknownKeysError listing the keys matching no field. The keys of nested
+// maps are all reported together.
+func WithErrorUnknownKeys(strict bool) Option {
+	return func(c *Converter) {
+		c.errorUnknownKeys = strict
+	}
+}
+
+// ArrayLenPolicy is how an array is filled from a source (a slice, an array,
+// a struct or a scalar) of another length.
+type ArrayLenPolicy int
+
+const (
+	// ArrayTruncate drops the extra source elements of a longer source, and
+	// zeroes the elements after the source ones of a shorter source.
+	// It is the default.
+	ArrayTruncate ArrayLenPolicy = iota
+	// ArrayPad zeroes the elements after the source ones of a shorter source,
+	// and fails with an ArrayLenError for a longer source.
+	ArrayPad
+	// ArrayStrict fails with an ArrayLenError unless the lengths match.
+	ArrayStrict
+)
+
+// WithArrayLenPolicy sets how an array is filled from a source of another
+// length, ArrayTruncate by default.
+func WithArrayLenPolicy(p ArrayLenPolicy) Option {
+	return func(c *Converter) {
+		c.arrayLenPolicy = p
+	}
+}
+
+// SliceMergeMode is how a source is converted to a non-empty slice.
+type SliceMergeMode int
+
+const (
+	// SliceReplace replaces the slice by the source elements. It is the default.
+	SliceReplace SliceMergeMode = iota
+	// SliceAppend appends the source elements to the slice.
+	SliceAppend
+	// SliceMergeByIndex converts each source element into the element of
+	// the same index, growing the slice if the source is longer.
+	SliceMergeByIndex
+)
+
+// WithSliceMergeMode sets how a source is converted to a non-empty slice,
+// SliceReplace by default.
+func WithSliceMergeMode(m SliceMergeMode) Option {
+	return func(c *Converter) {
+		c.sliceMergeMode = m
+	}
+}
+
+// MapMergeMode is how a source is converted to a non-empty map.
+type MapMergeMode int
+
+const (
+	// MapReplace replaces the map by a new one of the source elements.
+	// It is the default.
+	MapReplace MapMergeMode = iota
+	// MapMergeShallow keeps the keys missing in the source, and replaces
+	// the elements of the others.
+	MapMergeShallow
+	// MapMergeDeep is like MapMergeShallow, but converts the source elements
+	// into the existing ones, merging nested maps and structs recursively.
+	MapMergeDeep
+)
+
+// WithMapMergeMode sets how a source is converted to a non-empty map,
+// MapReplace by default.
+func WithMapMergeMode(m MapMergeMode) Option {
+	return func(c *Converter) {
+		c.mapMergeMode = m
+	}
+}
+
+// NullPolicy is how a null source, nil or a nil pointer (e.g. a YAML "~"),
+// is converted. The ...Or functions, like ToDurationOr, return their default
+// for a null source whatever the policy.
+type NullPolicy int
+
+const (
+	// NullZero sets the destination to its zero value, nil for pointers,
+	// slices, maps and interfaces. It is the default.
+	NullZero NullPolicy = iota
+	// NullSkip leaves the destination unchanged.
+	NullSkip
+	// NullError fails with ErrNull.
+	NullError
+)
+
+// WithNullPolicy sets how a null source is converted, NullZero by default.
+func WithNullPolicy(p NullPolicy) Option {
+	return func(c *Converter) {
+		c.nullPolicy = p
+	}
+}
+
+// WithDeepCopy sets whether the sources converted to interfaces, and the
+// values of the type of their destination, are copied deeply instead of
+// aliased, so that changing one doesn't change the other, see Clone.
+func WithDeepCopy(deep bool) Option {
+	return func(c *Converter) {
+		c.deepCopy = deep
+	}
+}
+
+// WithMaxDepth sets the max depth of nested values converted, beyond which
+// the conversion fails with a MaxDepthError. It is DefaultMaxDepth by default,
+// and unlimited if depth <= 0.
+func WithMaxDepth(depth int) Option {
+	return func(c *Converter) {
+		c.maxDepth = depth
+	}
+}
+
+// To convert src to dst, dst must be a pointer
+func (c *Converter) To(src, dst interface{}) error {
+	return c.decode(&decoder{Converter: c}, src, dst)
+}
+
+func (c *Converter) decode(d *decoder, src, dst interface{}) error {
+	if c.weak {
+		return d.weakTo(src, dst)
+	}
+	return d.to(src, dst)
+}
+
+func (c *Converter) runHooks(src reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	for _, hook := range c.hooks {
+		var err error
+		if src, err = hook(src, typ); err != nil {
+			return src, err
+		}
+	}
+	return src, nil
+}
+
+// layouts returns the layouts of time.Time, the first one formats it.
+func (c *Converter) layouts() []string {
+	switch {
+	case c.globalTimeLayout:
+		return []string{TimeLayout}
+	case len(c.timeLayouts) == 0:
+		return []string{DefaultTimeLayout}
+	default:
+		return c.timeLayouts
+	}
+}
+
+func (c *Converter) parseTime(s string) (t time.Time, err error) {
+	for _, layout := range c.layouts() {
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return t, err
+}
+
+// decoder holds the state of a single conversion by its Converter.
+type decoder struct {
+	*Converter
+
+	meta *Metadata  // nil if not requested
+	path []pathElem // path of the value being converted, tracked along meta
+
+	depth    int
+	visits   map[visit]reflect.Value
+	existing map[existingVisit]bool
+}
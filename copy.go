@@ -0,0 +1,117 @@
+package conv
+
+import "reflect"
+
+var cloneConverter = NewConverter(WithDeepCopy(true))
+
+// Clone copies src deeply to dst, dst must be a pointer. It converts like To
+// with WithDeepCopy(true), so that dst shares no pointers, maps or slices
+// with src.
+//
+// A struct of the type of its destination is copied with its unexported
+// fields, failing with a CannotCopyError if one of them holds references
+// (pointers, maps, slices, ...) that can't be copied. Values of the special
+// types (time.Time, regexp.Regexp, ...) are copied as is, except slices like
+// net.IP, and channels and functions are shared.
+func Clone(src, dst interface{}) error {
+	return cloneConverter.To(src, dst)
+}
+
+// DeepCopy returns a deep copy of v, see Clone.
+// It panics if v can't be copied.
+func DeepCopy[T any](v T) T {
+	var dst T
+	if err := cloneConverter.To(v, &dst); err != nil {
+		panic(err)
+	}
+	return dst
+}
+
+// copyTo copies src, or the value src points to, deeply to dst if it's of
+// the type of dst and isn't converted as any other value, and reports whether
+// it is.
+func (d *decoder) copyTo(src, dst reflect.Value) (bool, error) {
+	typ := dst.Type()
+	for src.Type() != typ {
+		if src.Kind() != reflect.Interface && src.Kind() != reflect.Ptr || src.IsNil() {
+			return false, nil
+		}
+		src = src.Elem()
+	}
+
+	switch {
+	case (typ.Kind() == reflect.Map || typ.Kind() == reflect.Slice) && src.IsNil() && dst.Len() == 0:
+		dst.Set(src) // not made empty
+		return true, nil
+
+	case d.isSpecialType(typ):
+		if typ.Kind() == reflect.Slice {
+			cp := reflect.MakeSlice(typ, src.Len(), src.Len())
+			reflect.Copy(cp, src)
+			src = cp
+		}
+		dst.Set(src)
+		return true, nil
+
+	case typ.Kind() == reflect.Struct:
+		return true, d.copyStruct(src, dst)
+
+	case typ.Kind() == reflect.Chan, typ.Kind() == reflect.Func,
+		typ.Kind() == reflect.UnsafePointer, typ.Kind() == reflect.Uintptr:
+		dst.Set(src)
+		return true, nil
+
+	default:
+		return false, nil
+	}
+}
+
+// copyStruct copies src deeply to dst of the same struct type, the unexported
+// fields shallowly if they hold no references.
+func (d *decoder) copyStruct(src, dst reflect.Value) error {
+	typ := src.Type()
+	cp := reflect.New(typ).Elem()
+	cp.Set(src)
+
+	var errs errorList
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			if holdsRefs(f.Type) {
+				return &CannotCopyError{f.Name, typ}
+			}
+			continue
+		}
+
+		srcField, dstField := src.Field(i), cp.Field(i)
+		dstField.Set(reflect.Zero(f.Type))
+		elem := nameElem(f.Name)
+		if err := d.toElem(d.to0, elem, srcField, dstField); err != nil {
+			if err = d.fieldError(&errs, err, elem, srcField, dstField); err != nil {
+				return err
+			}
+		}
+	}
+
+	dst.Set(cp)
+	return d.joinErrors(errs)
+}
+
+// holdsRefs reports whether the values of typ hold references, which
+// a shallow copy shares.
+func holdsRefs(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map,
+		reflect.Ptr, reflect.Slice, reflect.UnsafePointer:
+		return true
+	case reflect.Array:
+		return holdsRefs(typ.Elem())
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if holdsRefs(typ.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package conv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/maltegrosse/go-bytesize"
+)
+
+// ToMap convert src (a struct or a map) to a map tree
+func ToMap(src interface{}) (map[string]interface{}, error) {
+	return defaultConverter.ToMap(src)
+}
+
+// ToMap convert src (a struct or a map) to a map tree
+//
+// Nested structs and maps are converted to map[string]interface{},
+// slices and arrays to []interface{}, and the values of a type with an
+// encoder (time.Duration, time.Time, net.IP, url.URL, ByteSize, ... or
+// registered with RegisterEncoder or WithEncodeFunc) to the form they
+// convert back from with To.
+func (c *Converter) ToMap(src interface{}) (map[string]interface{}, error) {
+	srcv := indirect(reflect.ValueOf(src))
+	switch srcv.Kind() {
+	case reflect.Struct, reflect.Map:
+		if _, ok := c.lookupEncoder(srcv.Type()); ok || isTextMarshaler(srcv.Type()) {
+			return nil, &CannotConvError{srcv.Kind(), reflect.Map}
+		}
+
+	default:
+		return nil, &CannotConvError{srcv.Kind(), reflect.Map}
+	}
+
+	v, err := c.encode(srcv, 1)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := v.(map[string]interface{})
+	return m, nil
+}
+
+func (c *Converter) encode(v reflect.Value, depth int) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if c.maxDepth > 0 && depth > c.maxDepth {
+		return nil, &MaxDepthError{c.maxDepth}
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+	}
+
+	if fn, ok := c.lookupEncoder(v.Type()); ok {
+		return fn(c, v)
+	}
+	if text, ok, err := marshalText(v); ok {
+		if err != nil {
+			return nil, err
+		}
+		return text, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Complex64, reflect.Complex128,
+		reflect.String:
+		return v.Interface(), nil
+
+	case reflect.Interface, reflect.Ptr:
+		return c.encode(v.Elem(), depth+1)
+
+	case reflect.Slice, reflect.Array:
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			elem, err := c.encode(v.Index(i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = elem
+		}
+		return s, nil
+
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var key string
+			if text, ok, err := marshalText(iter.Key()); ok {
+				if err != nil {
+					return nil, err
+				}
+				key = text
+			} else if err := weakToString(iter.Key(), reflect.ValueOf(&key).Elem()); err != nil {
+				return nil, err
+			}
+			elem, err := c.encode(iter.Value(), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = elem
+		}
+		return m, nil
+
+	case reflect.Struct:
+		m := make(map[string]interface{}, v.NumField())
+		for _, f := range c.structFields(v.Type()) {
+			if !f.IsExported() {
+				continue
+			}
+
+			field, err := v.FieldByIndexErr(f.Index)
+			if err != nil || f.tag.omitEmpty && isEmptyValue(field) {
+				continue
+			}
+			elem, err := c.encode(field, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			m[f.key] = elem
+		}
+		return m, nil
+
+	default:
+		return nil, &CannotConvError{v.Kind(), reflect.Interface}
+	}
+}
+
+func encodeStringer(_ *Converter, v reflect.Value) (interface{}, error) {
+	// url.URL, mail.Address and regexp.Regexp implement fmt.Stringer on pointer
+	if v.Kind() == reflect.Struct {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+	return v.Interface().(fmt.Stringer).String(), nil
+}
+
+func encodeTimeTime(c *Converter, v reflect.Value) (interface{}, error) {
+	return v.Interface().(time.Time).Format(c.layouts()[0]), nil
+}
+
+// encodeByteSize renders the byte size with the largest unit dividing it exactly.
+func encodeByteSize(_ *Converter, v reflect.Value) (interface{}, error) {
+	b := bytesize.ByteSize(v.Uint())
+	units := []struct {
+		size bytesize.ByteSize
+		name string
+	}{
+		{bytesize.EB, "EB"},
+		{bytesize.PB, "PB"},
+		{bytesize.TB, "TB"},
+		{bytesize.GB, "GB"},
+		{bytesize.MB, "MB"},
+		{bytesize.KB, "KB"},
+	}
+	for _, unit := range units {
+		if b != 0 && b%unit.size == 0 {
+			return strconv.FormatUint(uint64(b/unit.size), 10) + unit.name, nil
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B", nil
+}
@@ -1,10 +1,19 @@
 package conv
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
+// ErrRequired is the error of a required field missing in the source map,
+// wrapped in a FieldError.
+var ErrRequired = errors.New("required field is missing")
+
+// ErrNull is the error of a null source with the NullError policy.
+var ErrNull = errors.New("cannot convert null")
+
 // CannotConvError ...
 type CannotConvError struct {
 	srcKind reflect.Kind
@@ -18,7 +27,68 @@ type OverflowError struct {
 	dstKind reflect.Kind
 }
 
-type CannotSetError struct{}
+// CannotSetError is the error of a destination value that cannot be set,
+// such as an unexported embedded pointer to allocate.
+type CannotSetError struct {
+	Field string // the struct field, empty if the value isn't one or is unknown
+	Type  reflect.Type
+}
+
+// CannotCopyError is the error of copying deeply a struct whose unexported
+// field holds references (pointers, maps, slices, ...), see Clone.
+type CannotCopyError struct {
+	Field string
+	Type  reflect.Type // the struct type
+}
+
+// InterfaceError is the error of a source whose type, or pointer to it,
+// doesn't implement the interface of the destination.
+type InterfaceError struct {
+	SrcType reflect.Type
+	DstType reflect.Type
+}
+
+// FieldError is an error converting the value at Path of a composite value,
+// e.g. "servers[2].tls.cert".
+type FieldError struct {
+	Path    string
+	Src     interface{}  // the source value, nil if invalid or unexported
+	SrcType reflect.Type // nil if the source value is invalid
+	DstType reflect.Type
+	Err     error
+}
+
+// UnknownKeysError is the paths of the source map keys matching no field,
+// see WithErrorUnknownKeys.
+type UnknownKeysError struct {
+	Paths []string
+}
+
+// FieldConflictError is the error of a key matching the fields promoted
+// from embedded structs that hide each other.
+type FieldConflictError struct {
+	Type   reflect.Type
+	Key    string
+	Fields []string
+}
+
+// ArrayLenError is the error of a source whose length doesn't match the
+// destination array, see WithArrayLenPolicy.
+type ArrayLenError struct {
+	SrcLen int
+	DstLen int
+}
+
+// MaxDepthError is the error of a source nested deeper than the max depth,
+// see WithMaxDepth.
+type MaxDepthError struct {
+	MaxDepth int
+}
+
+// MultiError is the errors of all failed conversions, see WithAllErrors.
+type MultiError struct {
+	Errors []error
+}
 
 func (e *CannotConvError) Error() string {
 	return fmt.Sprintf("cannot convert %s to %s", e.srcKind, e.dstKind)
@@ -29,5 +99,153 @@ func (e *OverflowError) Error() string {
 }
 
 func (e *CannotSetError) Error() string {
-	return fmt.Sprintf("cannot set")
+	if e.Field != "" {
+		return fmt.Sprintf("cannot set field %s of type %s", e.Field, e.Type)
+	}
+	return fmt.Sprintf("cannot set value of type %s", e.Type)
+}
+
+func (e *CannotCopyError) Error() string {
+	return fmt.Sprintf("cannot copy unexported field %s of %s", e.Field, e.Type)
+}
+
+func (e *InterfaceError) Error() string {
+	return fmt.Sprintf("%s does not implement %s", e.SrcType, e.DstType)
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// wrapFieldError wraps err of converting src to dst, the element name of its
+// composite value, into a FieldError, or prefixes the paths if it's already
+// a FieldError or a MultiError.
+func wrapFieldError(err error, name string, src, dst reflect.Value) error {
+	if _, ok := err.(*MaxDepthError); ok {
+		return err // the path would be as deep
+	}
+
+	if me, ok := err.(*MultiError); ok {
+		for i, err := range me.Errors {
+			me.Errors[i] = wrapFieldError(err, name, src, dst)
+		}
+		return me
+	}
+
+	if ue, ok := err.(*UnknownKeysError); ok {
+		for i, path := range ue.Paths {
+			ue.Paths[i] = joinPath(name, path)
+		}
+		return ue
+	}
+
+	if fe, ok := err.(*FieldError); ok {
+		fe.Path = joinPath(name, fe.Path)
+		return fe
+	}
+
+	for src.Kind() == reflect.Interface && !src.IsNil() {
+		src = src.Elem()
+	}
+	fe := &FieldError{Path: name, Err: err}
+	if dst.IsValid() {
+		fe.DstType = dst.Type()
+	}
+	if src.IsValid() {
+		fe.SrcType = src.Type()
+		if src.CanInterface() {
+			fe.Src = src.Interface()
+		}
+	}
+	return fe
+}
+
+func (e *FieldConflictError) Error() string {
+	return fmt.Sprintf("key %q matches conflicting fields %s of %s", e.Key, strings.Join(e.Fields, ", "), e.Type)
+}
+
+func (e *ArrayLenError) Error() string {
+	return fmt.Sprintf("cannot convert %d elements to an array of length %d", e.SrcLen, e.DstLen)
+}
+
+func (e *MaxDepthError) Error() string {
+	return fmt.Sprintf("exceeded max depth %d", e.MaxDepth)
+}
+
+func (e *UnknownKeysError) Error() string {
+	return "unknown keys: " + strings.Join(e.Paths, ", ")
+}
+
+func (e *MultiError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors for errors.Is and errors.As (Go 1.20+).
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// isUnknownKeys reports whether err only reports the unknown keys of a value
+// otherwise converted, to keep.
+func isUnknownKeys(err error) bool {
+	_, ok := err.(*UnknownKeysError)
+	return ok
+}
+
+// errorList collects the errors of the elements of a composite value.
+type errorList []error
+
+// fieldError wraps err of converting an element as wrapFieldError does.
+// It returns the error to stop converting with, or adds it to errs and
+// returns nil if all errors are collected.
+func (d *decoder) fieldError(errs *errorList, err error, elem pathElem, src, dst reflect.Value) error {
+	err = wrapFieldError(err, elem.String(), src, dst)
+	if _, ok := err.(*UnknownKeysError); !ok && !d.allErrors {
+		return err
+	}
+
+	if me, ok := err.(*MultiError); ok {
+		*errs = append(*errs, me.Errors...)
+	} else {
+		*errs = append(*errs, err)
+	}
+	return nil
+}
+
+// joinErrors returns the collected errors as one, the unknown keys are merged
+// into one UnknownKeysError.
+func (d *decoder) joinErrors(errs errorList) error {
+	var (
+		unknown *UnknownKeysError
+		joined  errorList
+	)
+	for _, err := range errs {
+		ue, ok := err.(*UnknownKeysError)
+		switch {
+		case !ok:
+			joined = append(joined, err)
+		case unknown == nil:
+			unknown = ue
+			joined = append(joined, ue)
+		default:
+			unknown.Paths = append(unknown.Paths, ue.Paths...)
+		}
+	}
+
+	switch {
+	case len(joined) == 0:
+		return nil
+	case len(joined) == 1 && unknown != nil:
+		return unknown
+	default:
+		return &MultiError{joined}
+	}
 }
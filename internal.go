@@ -8,8 +8,8 @@ import (
 	"net/url"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/maltegrosse/go-bytesize"
@@ -184,11 +184,12 @@ func toComplex(src, dst reflect.Value) error {
 	return nil
 }
 
-func toArray(src, dst reflect.Value) error {
-	return toArray0(src, dst, to0)
+func (d *decoder) toArray(src, dst reflect.Value) error {
+	return d.toArray0(src, dst, d.to0)
 }
 
-func toArray0(src, dst reflect.Value, to func(src, dst reflect.Value) error) error {
+func (d *decoder) toArray0(src, dst reflect.Value, to func(src, dst reflect.Value) error) error {
+	var errs errorList
 	switch src.Kind() {
 	case reflect.Bool:
 		fallthrough
@@ -199,62 +200,119 @@ func toArray0(src, dst reflect.Value, to func(src, dst reflect.Value) error) err
 	case reflect.Float32, reflect.Float64:
 		fallthrough
 	case reflect.Complex64, reflect.Complex128:
-		if dst.Cap() <= 0 {
-			break // TODO
+		if err := d.checkArrayLen(1, dst); err != nil {
+			return err
+		}
+		if dst.Len() == 0 {
+			break
 		}
 
 		dstElem := dst.Index(0)
-		if err := to(src, dstElem); err != nil {
-			return err
+		elem := indexElem(0)
+		if err := d.toElem(to, elem, src, dstElem); err != nil {
+			if err = d.fieldError(&errs, err, elem, src, dstElem); err != nil {
+				return err
+			}
 		}
 
 	case reflect.Array, reflect.Slice:
-		for i := 0; i < src.Len(); i++ {
-			if i >= dst.Cap() {
-				break // TODO
-			}
+		if err := d.checkArrayLen(src.Len(), dst); err != nil {
+			return err
+		}
 
+		for i := 0; i < src.Len() && i < dst.Len(); i++ {
 			srcElem := src.Index(i)
 			dstElem := dst.Index(i)
-			if err := to(srcElem, dstElem); err != nil {
-				return err
+			elem := indexElem(i)
+			if err := d.toElem(to, elem, srcElem, dstElem); err != nil {
+				if err = d.fieldError(&errs, err, elem, srcElem, dstElem); err != nil {
+					return err
+				}
 			}
 		}
 
 	case reflect.Struct:
-		for i := 0; i < src.NumField(); i++ {
-			if i >= dst.Cap() {
-				break // TODO
-			}
+		fields := exportedFields(src.Type())
+		if err := d.checkArrayLen(len(fields), dst); err != nil {
+			return err
+		}
 
-			srcElem := src.Field(i)
+		for i := 0; i < len(fields) && i < dst.Len(); i++ {
+			srcElem := src.Field(fields[i])
 			dstElem := dst.Index(i)
 
-			if err := to(srcElem, dstElem); err != nil {
-				return err
+			elem := nameElem(src.Type().Field(fields[i]).Name)
+			if err := d.toElem(to, elem, srcElem, dstElem); err != nil {
+				if err = d.fieldError(&errs, err, elem, srcElem, dstElem); err != nil {
+					return err
+				}
 			}
 		}
 
 	case reflect.Interface, reflect.Ptr:
-		return toArray0(indirect(src), dst, to)
+		return d.toArray0(indirect(src), dst, to)
 
 	default:
 		return &CannotConvError{src.Kind(), dst.Kind()}
 	}
 
+	return d.joinErrors(errs)
+}
+
+// checkArrayLen applies the ArrayLenPolicy to a source of n elements
+// converted to the array dst.
+func (d *decoder) checkArrayLen(n int, dst reflect.Value) error {
+	if n == dst.Len() {
+		return nil
+	}
+
+	if d.arrayLenPolicy == ArrayStrict || d.arrayLenPolicy == ArrayPad && n > dst.Len() {
+		return &ArrayLenError{n, dst.Len()}
+	}
+	zero := reflect.Zero(dst.Type().Elem())
+	for i := n; i < dst.Len(); i++ {
+		dst.Index(i).Set(zero)
+	}
 	return nil
 }
 
-func toInterface(src, dst reflect.Value) error {
+// toInterface sets dst to src, or a pointer to a copy of src if only the
+// pointer implements the interface of dst.
+func (d *decoder) toInterface(src, dst reflect.Value) error {
+	for src.Kind() == reflect.Interface {
+		src = src.Elem()
+	}
+
+	typ := dst.Type()
+	switch {
+	case src.Type().Implements(typ):
+	case reflect.PtrTo(src.Type()).Implements(typ):
+		ptr := reflect.New(src.Type())
+		ptr.Elem().Set(src)
+		src = ptr
+	default:
+		return &InterfaceError{src.Type(), typ}
+	}
+
+	if d.deepCopy {
+		cp := reflect.New(src.Type()).Elem()
+		err := d.to0(src, cp)
+		if err != nil && !isUnknownKeys(err) {
+			return err
+		}
+		dst.Set(cp)
+		return err
+	}
 	dst.Set(src)
 	return nil
 }
 
-func toMap(src, dst reflect.Value) error {
-	return toMap0(src, dst, to0)
+func (d *decoder) toMap(src, dst reflect.Value) error {
+	return d.toMap0(src, dst, d.to0)
 }
 
-func toMap0(src, dst reflect.Value, to func(src, dst reflect.Value) error) error {
+func (d *decoder) toMap0(src, dst reflect.Value, to func(src, dst reflect.Value) error) error {
+	var errs errorList
 	switch src.Kind() {
 	case reflect.Bool:
 		fallthrough
@@ -265,95 +323,198 @@ func toMap0(src, dst reflect.Value, to func(src, dst reflect.Value) error) error
 	case reflect.Float32, reflect.Float64:
 		fallthrough
 	case reflect.Complex64, reflect.Complex128:
-		if dst.IsNil() {
-			dst.Set(reflect.MakeMapWithSize(dst.Type(), 1))
-		}
+		d.makeMap(dst, 1)
 		key := reflect.Zero(dst.Type().Key())
-		dstElem := mapIndex(dst, key)
-		if err := to(src, dstElem); err != nil {
-			return err
+		dstElem := d.mapElem(dst, key, src)
+		elem := keyElem(key)
+		if err := d.toElem(to, elem, src, dstElem); err != nil {
+			unknown := isUnknownKeys(err)
+			if err = d.fieldError(&errs, err, elem, src, dstElem); err != nil {
+				return err
+			}
+			if !unknown {
+				break
+			}
 		}
 		dst.SetMapIndex(key, dstElem)
 
 	case reflect.Map:
-		if dst.IsNil() {
-			dst.Set(reflect.MakeMapWithSize(dst.Type(), src.Len()))
+		// only new maps are shared, the merged ones are of their destination
+		fresh := dst.IsNil() || d.mapMergeMode == MapReplace
+		if fresh && d.visited(src, dst) {
+			return nil
+		}
+		d.makeMap(dst, src.Len())
+		if fresh {
+			d.visit(src, dst)
 		}
 
 		iter := src.MapRange()
 		for iter.Next() {
-			key := iter.Key()
+			elem := keyElem(iter.Key())
+			key, err := d.mapKey(iter.Key(), dst, to)
+			if err != nil {
+				if err = d.fieldError(&errs, err, elem, iter.Key(), key); err != nil {
+					return err
+				}
+				continue
+			}
 
 			srcElem := iter.Value()
-			dstElem := mapIndex(dst, key)
-			if err := to(srcElem, dstElem); err != nil {
-				return err
+			dstElem := d.mapElem(dst, key, srcElem)
+			if err := d.toElem(to, elem, srcElem, dstElem); err != nil {
+				unknown := isUnknownKeys(err)
+				if err = d.fieldError(&errs, err, elem, srcElem, dstElem); err != nil {
+					return err
+				}
+				if !unknown {
+					continue
+				}
 			}
 			dst.SetMapIndex(key, dstElem)
 		}
 
 	case reflect.Slice, reflect.Array:
-		if dst.IsNil() {
-			dst.Set(reflect.MakeMapWithSize(dst.Type(), src.Len()))
-		}
+		d.makeMap(dst, src.Len())
 
 		for i := 0; i < src.Len(); i++ {
-			key := reflect.ValueOf(i) // TODO: check map key type
-			srcElem := src.Index(i)
-			dstElem := mapIndex(dst, key)
+			elem := indexElem(i)
+			key, err := d.mapKey(reflect.ValueOf(i), dst, to)
+			if err != nil {
+				if err = d.fieldError(&errs, err, elem, reflect.ValueOf(i), key); err != nil {
+					return err
+				}
+				continue
+			}
 
-			if err := to(srcElem, dstElem); err != nil {
-				return err
+			srcElem := src.Index(i)
+			dstElem := d.mapElem(dst, key, srcElem)
+			if err := d.toElem(to, elem, srcElem, dstElem); err != nil {
+				unknown := isUnknownKeys(err)
+				if err = d.fieldError(&errs, err, elem, srcElem, dstElem); err != nil {
+					return err
+				}
+				if !unknown {
+					continue
+				}
 			}
 			dst.SetMapIndex(key, dstElem)
 		}
 
 	case reflect.Struct:
-		if dst.IsNil() {
-			dst.Set(reflect.MakeMapWithSize(dst.Type(), src.NumField()))
-		}
+		d.makeMap(dst, src.NumField())
 
-		for i := 0; i < src.NumField(); i++ {
-			name := src.Type().Field(i).Name
-			key := reflect.ValueOf(name) // TODO: check map key type
+		for _, f := range d.structFields(src.Type()) {
+			if !f.IsExported() {
+				continue
+			}
+			srcField, err := src.FieldByIndexErr(f.Index)
+			if err != nil || f.tag.omitEmpty && isEmptyValue(srcField) {
+				continue
+			}
 
-			srcField := src.Field(i)
-			dstElem := mapIndex(dst, key)
+			elem := nameElem(f.key)
+			key, err := d.mapKey(reflect.ValueOf(f.key), dst, to)
+			if err != nil {
+				if err = d.fieldError(&errs, err, elem, reflect.ValueOf(f.key), key); err != nil {
+					return err
+				}
+				continue
+			}
 
-			if err := to(srcField, dstElem); err != nil {
-				return err
+			dstElem := d.mapElem(dst, key, srcField)
+			if err := d.toElem(to, elem, srcField, dstElem); err != nil {
+				unknown := isUnknownKeys(err)
+				if err = d.fieldError(&errs, err, elem, srcField, dstElem); err != nil {
+					return err
+				}
+				if !unknown {
+					continue
+				}
 			}
 			dst.SetMapIndex(key, dstElem)
 		}
 
 	case reflect.Interface, reflect.Ptr:
-		return toMap0(indirect(src), dst, to)
+		return d.toMap0(indirect(src), dst, to)
 
 	default:
 		return &CannotConvError{src.Kind(), dst.Kind()}
 	}
 
-	return nil
+	return d.joinErrors(errs)
+}
+
+// makeMap prepares the map dst for n source elements according to the
+// MapMergeMode.
+func (d *decoder) makeMap(dst reflect.Value, n int) {
+	if dst.IsNil() || d.mapMergeMode == MapReplace {
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), n))
+	}
 }
 
-func toPtr(src, dst reflect.Value) error {
+// mapElem returns a settable value to convert src of key into, a copy of the
+// existing element under MapMergeDeep, otherwise a zero one. An interface
+// element holding a map is unwrapped to merge into the map.
+func (d *decoder) mapElem(m, key, src reflect.Value) reflect.Value {
+	elem := reflect.New(m.Type().Elem()).Elem()
+	if d.mapMergeMode != MapMergeDeep {
+		return elem
+	}
+
+	old := m.MapIndex(key)
+	if !old.IsValid() {
+		return elem
+	}
+	if old.Kind() == reflect.Interface && !old.IsNil() && old.Elem().Kind() == reflect.Map {
+		switch indirect(src).Kind() {
+		case reflect.Map, reflect.Struct:
+			old = old.Elem()
+			elem = reflect.New(old.Type()).Elem()
+		}
+	}
+	elem.Set(old)
+	return elem
+}
+
+// mapKey converts the key src to the key type of the map dst.
+// The returned key is settable even if the conversion fails.
+func (d *decoder) mapKey(src, dst reflect.Value, to func(src, dst reflect.Value) error) (reflect.Value, error) {
+	key := reflect.New(dst.Type().Key()).Elem()
+	return key, to(src, key)
+}
+
+func (d *decoder) toPtr(src, dst reflect.Value) error {
+	return d.toPtr0(src, dst, d.to0)
+}
+
+func (d *decoder) toPtr0(src, dst reflect.Value, to func(src, dst reflect.Value) error) error {
+	// only new pointers are shared, the existing ones are converted into
 	realdst := dst
 	if dst.IsNil() {
+		if d.visited(src, dst) {
+			return nil
+		}
 		realdst = reflect.New(dst.Type().Elem())
+		d.visit(src, realdst)
+	} else if d.visitExisting(src, dst) {
+		return nil
 	}
-	if err := to0(src, realdst.Elem()); err != nil {
+	err := to(src, realdst.Elem())
+	if err != nil && !isUnknownKeys(err) {
 		return err
 	}
 	dst.Set(realdst)
 
-	return nil
+	return err
 }
 
-func toSlice(src, dst reflect.Value) error {
-	return toSlice0(src, dst, to0)
+func (d *decoder) toSlice(src, dst reflect.Value) error {
+	return d.toSlice0(src, dst, d.to0)
 }
 
-func toSlice0(src, dst reflect.Value, to func(src, dst reflect.Value) error) error {
+func (d *decoder) toSlice0(src, dst reflect.Value, to func(src, dst reflect.Value) error) error {
+	var errs errorList
 	switch src.Kind() {
 	case reflect.Bool:
 		fallthrough
@@ -364,59 +525,86 @@ func toSlice0(src, dst reflect.Value, to func(src, dst reflect.Value) error) err
 	case reflect.Float32, reflect.Float64:
 		fallthrough
 	case reflect.Complex64, reflect.Complex128:
-		if dst.IsNil() {
-			dst.Set(reflect.MakeSlice(dst.Type(), 1, 1))
-		}
-
-		dstElem := sliceIndex(dst, 0)
-		if err := to(src, dstElem); err != nil {
-			return err
+		off := d.growSlice(dst, 1)
+		dstElem := dst.Index(off)
+		elem := indexElem(0)
+		if err := d.toElem(to, elem, src, dstElem); err != nil {
+			if err = d.fieldError(&errs, err, elem, src, dstElem); err != nil {
+				return err
+			}
 		}
 
 	case reflect.Array, reflect.Slice:
-		if dst.IsNil() {
-			dst.Set(reflect.MakeSlice(dst.Type(), src.Len(), src.Len()))
-		}
-
+		off := d.growSlice(dst, src.Len())
 		for i := 0; i < src.Len(); i++ {
 			srcElem := src.Index(i)
-			dstElem := sliceIndex(dst, i)
+			dstElem := dst.Index(off + i)
 
-			if err := to(srcElem, dstElem); err != nil {
-				return err
+			elem := indexElem(i)
+			if err := d.toElem(to, elem, srcElem, dstElem); err != nil {
+				if err = d.fieldError(&errs, err, elem, srcElem, dstElem); err != nil {
+					return err
+				}
 			}
 		}
 
 	case reflect.Struct:
-		if dst.IsNil() {
-			dst.Set(reflect.MakeSlice(dst.Type(), src.NumField(), src.NumField()))
-		}
-
-		for i := 0; i < src.NumField(); i++ {
-			srcElem := src.Field(i)
-			dstElem := sliceIndex(dst, i)
-
-			if err := to(srcElem, dstElem); err != nil {
-				return err
+		fields := exportedFields(src.Type())
+		off := d.growSlice(dst, len(fields))
+		for i, x := range fields {
+			srcElem := src.Field(x)
+			dstElem := dst.Index(off + i)
+
+			elem := nameElem(src.Type().Field(x).Name)
+			if err := d.toElem(to, elem, srcElem, dstElem); err != nil {
+				if err = d.fieldError(&errs, err, elem, srcElem, dstElem); err != nil {
+					return err
+				}
 			}
 		}
 
 	case reflect.Interface, reflect.Ptr:
-		return toSlice0(indirect(src), dst, to)
+		return d.toSlice0(indirect(src), dst, to)
 
 	default:
 		return &CannotConvError{src.Kind(), dst.Kind()}
 	}
 
-	return nil
+	return d.joinErrors(errs)
+}
+
+// growSlice prepares the slice dst for n source elements according to the
+// SliceMergeMode, and returns the index of the element of the first one.
+func (d *decoder) growSlice(dst reflect.Value, n int) int {
+	switch d.sliceMergeMode {
+	case SliceAppend:
+		off := dst.Len()
+		dst.Set(reflect.AppendSlice(dst, reflect.MakeSlice(dst.Type(), n, n)))
+		return off
+
+	case SliceMergeByIndex:
+		if n > dst.Len() {
+			dst.Set(reflect.AppendSlice(dst, reflect.MakeSlice(dst.Type(), n-dst.Len(), n-dst.Len())))
+		}
+		return 0
+
+	default:
+		dst.Set(reflect.MakeSlice(dst.Type(), n, n))
+		return 0
+	}
 }
 
 func toString(src, dst reflect.Value) error {
+	if text, ok, err := marshalText(src); ok {
+		if err != nil {
+			return err
+		}
+		dst.SetString(text)
+		return nil
+	}
 	stringer := reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
-	if src.Type().Implements(stringer) {
-		string, _ := src.Type().MethodByName("String")
-		s := string.Func.Call(nil)[0].String()
-		dst.SetString(s)
+	if src.Type().Implements(stringer) && src.CanInterface() {
+		dst.SetString(src.Interface().(fmt.Stringer).String())
 		return nil
 	}
 
@@ -426,7 +614,7 @@ func toString(src, dst reflect.Value) error {
 		dst.SetString(src.String())
 
 	case reflect.Interface, reflect.Ptr:
-		toString(indirect(src), dst)
+		return toString(indirect(src), dst)
 
 	default:
 		return &CannotConvError{src.Kind(), dst.Kind()}
@@ -435,11 +623,43 @@ func toString(src, dst reflect.Value) error {
 	return nil
 }
 
-func toStruct(src, dst reflect.Value) error {
-	return toStruct0(src, dst, to0)
+func (d *decoder) toStruct(src, dst reflect.Value) error {
+	return d.toStruct0(src, dst, d.to0)
 }
 
-func toStruct0(src, dst reflect.Value, to func(src, dst reflect.Value) error) error {
+// fieldKey returns the source map key naming a struct field. Interface keys
+// are unwrapped and the others converted like WeakTo, e.g. 1 to "1" or
+// a fmt.Stringer to its String.
+func fieldKey(key reflect.Value) string {
+	for key.Kind() == reflect.Interface && !key.IsNil() {
+		key = key.Elem()
+	}
+	switch key.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if key.IsNil() {
+			return ""
+		}
+	}
+
+	var s string
+	_ = weakToString(key, reflect.ValueOf(&s).Elem())
+	return s
+}
+
+// exportedFields returns the indexes of the exported fields of the struct
+// type typ, the elements of a struct converted to and from a sequence.
+func exportedFields(typ reflect.Type) []int {
+	var fields []int
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).IsExported() {
+			fields = append(fields, i)
+		}
+	}
+	return fields
+}
+
+func (d *decoder) toStruct0(src, dst reflect.Value, to func(src, dst reflect.Value) error) error {
+	var errs errorList
 	switch src.Kind() {
 	case reflect.Bool:
 		fallthrough
@@ -453,9 +673,17 @@ func toStruct0(src, dst reflect.Value, to func(src, dst reflect.Value) error) er
 		if dst.NumField() == 0 {
 			return nil
 		}
-		dstElem := dst.Field(0)
-		if err := to(src, dstElem); err != nil {
-			return err
+		fields := exportedFields(dst.Type())
+		if len(fields) == 0 { // e.g. time.Time
+			return &CannotConvError{src.Kind(), dst.Kind()}
+		}
+		i := fields[0]
+		dstElem := dst.Field(i)
+		elem := nameElem(dst.Type().Field(i).Name)
+		if err := d.toElem(to, elem, src, dstElem); err != nil {
+			if err = d.fieldError(&errs, err, elem, src, dstElem); err != nil {
+				return err
+			}
 		}
 
 	case reflect.Slice, reflect.Array:
@@ -464,72 +692,209 @@ func toStruct0(src, dst reflect.Value, to func(src, dst reflect.Value) error) er
 				break
 			}
 
+			if !dst.Type().Field(i).IsExported() {
+				continue // the source element of the field is dropped
+			}
 			srcElem := src.Index(i)
 			dstElem := dst.Field(i)
 
-			if dstElem.Kind() == reflect.Invalid {
-				continue // TODO
-			}
-
-			if err := to(srcElem, dstElem); err != nil {
-				return err
+			elem := indexElem(i)
+			if err := d.toElem(to, elem, srcElem, dstElem); err != nil {
+				if err = d.fieldError(&errs, err, elem, srcElem, dstElem); err != nil {
+					return err
+				}
 			}
 		}
 
 	case reflect.Map:
+		var (
+			unknown []string
+			set     [][]int // indexes of the fields converted to
+		)
 		iter := src.MapRange()
 		for iter.Next() {
-			srcKey := iter.Key().String()
-
-			isMatchCase := true
-			if srcKey == strings.ToLower(srcKey) {
-				isMatchCase = false
+			srcKey := fieldKey(iter.Key())
+
+			elem := nameElem(srcKey)
+			field, ok := d.structField(dst.Type(), srcKey)
+			if !ok { // not exist
+				if err := d.fieldConflict(dst.Type(), srcKey); err != nil {
+					if err = d.fieldError(&errs, err, elem, iter.Value(), reflect.Value{}); err != nil {
+						return err
+					}
+					continue
+				}
+				if d.errorUnknownKeys {
+					unknown = append(unknown, srcKey)
+				}
+				if d.meta != nil {
+					d.meta.Unused = append(d.meta.Unused, d.pathOf(srcKey))
+				}
+				continue
 			}
-
-			var dstField reflect.Value
-			if isMatchCase {
-				dstField = dst.FieldByName(srcKey)
-			} else {
-				dstField = dst.FieldByNameFunc(func(fn string) bool {
-					return strings.ToLower(fn) == srcKey
-				})
+			if (field.tag.required || field.tag.hasDefault) && isNull(iter.Value()) {
+				continue // missing, whatever the NullPolicy
 			}
-
-			if dstField.Kind() == reflect.Invalid { // not exist
+			dstField, commit, err := fieldByIndex(dst, field.Index)
+			if err != nil {
+				if err = d.fieldError(&errs, err, elem, iter.Value(), reflect.Value{}); err != nil {
+					return err
+				}
 				continue
 			}
+			set = append(set, field.Index)
+			if d.meta != nil {
+				d.meta.Keys = append(d.meta.Keys, d.pathOf(srcKey))
+			}
 
-			if err := to(iter.Value(), dstField); err != nil {
-				return err
+			if err := d.toElem(to, elem, iter.Value(), dstField); err != nil {
+				unknown := isUnknownKeys(err)
+				if err = d.fieldError(&errs, err, elem, iter.Value(), dstField); err != nil {
+					return err
+				}
+				if !unknown {
+					continue
+				}
 			}
+			commit()
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			errs = append(errs, &UnknownKeysError{unknown})
+		}
+		if d.meta != nil {
+			d.recordUnset(dst.Type(), set)
 		}
 
-	case reflect.Struct:
-		for i := 0; i < src.NumField(); i++ {
-			srcField := src.Field(i)
+		if err := d.missingFields(dst, set, &errs); err != nil {
+			return err
+		}
 
-			srcFname := src.Type().Field(i).Name
-			dstField := dst.FieldByName(srcFname)
+	case reflect.Struct:
+		var set [][]int // indexes of the fields converted to
+		for _, f := range d.structFields(src.Type()) {
+			if !f.IsExported() {
+				continue // never a key
+			}
+			srcField, err := src.FieldByIndexErr(f.Index)
+			if err != nil { // nil embedded pointer
+				continue
+			}
 
-			if dstField.Kind() == reflect.Invalid { // not exist field
+			elem := nameElem(f.key)
+			field, ok := d.structField(dst.Type(), f.key)
+			if !ok { // not exist field
+				if err := d.fieldConflict(dst.Type(), f.key); err != nil {
+					if err = d.fieldError(&errs, err, elem, srcField, reflect.Value{}); err != nil {
+						return err
+					}
+					continue
+				}
+				if d.meta != nil {
+					d.meta.Unused = append(d.meta.Unused, d.pathOf(f.key))
+				}
 				continue
 			}
+			dstField, commit, err := fieldByIndex(dst, field.Index)
+			if err != nil {
+				if err = d.fieldError(&errs, err, elem, srcField, reflect.Value{}); err != nil {
+					return err
+				}
+				continue
+			}
+			set = append(set, field.Index)
+			if d.meta != nil {
+				d.meta.Keys = append(d.meta.Keys, d.pathOf(f.key))
+			}
 
-			if err := to(srcField, dstField); err != nil {
-				return err
+			if err := d.toElem(to, elem, srcField, dstField); err != nil {
+				unknown := isUnknownKeys(err)
+				if err = d.fieldError(&errs, err, elem, srcField, dstField); err != nil {
+					return err
+				}
+				if !unknown {
+					continue
+				}
 			}
+			commit()
+		}
+		if d.meta != nil {
+			d.recordUnset(dst.Type(), set)
+		}
+		if err := d.missingFields(dst, set, &errs); err != nil {
+			return err
 		}
 
 	case reflect.Interface, reflect.Ptr:
-		return toSlice0(indirect(src), dst, to)
+		return d.toStruct0(indirect(src), dst, to)
 
 	default:
 		return &CannotConvError{src.Kind(), dst.Kind()}
 	}
 
+	return d.joinErrors(errs)
+}
+
+// missingFields applies the defaults of the fields of the struct dst no source
+// key is converted to, the ones not overlapping the fields of indexes set,
+// or fails with ErrRequired for the required ones. The nested structs of the
+// missing fields are missing too.
+func (d *decoder) missingFields(dst reflect.Value, set [][]int, errs *errorList) error {
+	for _, f := range d.structFields(dst.Type()) {
+		if !f.IsExported() || overlaps(f.Index, set) {
+			continue
+		}
+
+		elem := nameElem(f.key)
+		var (
+			srcElem  reflect.Value
+			dstField = reflect.Zero(f.Type) // for the type of the error
+			commit   func()
+			err      error
+		)
+		switch {
+		case f.tag.hasDefault:
+			srcElem = reflect.ValueOf(f.tag.def)
+			if dstField, commit, err = fieldByIndex(dst, f.Index); err == nil {
+				err = d.toElem(d.weakTo0, elem, srcElem, dstField)
+			}
+		case f.tag.required:
+			err = ErrRequired
+		case d.hasMissingRules(f.Type):
+			if dstField, commit, err = fieldByIndex(dst, f.Index); err == nil {
+				var nested errorList
+				if err = d.missingFields(dstField, nil, &nested); err == nil {
+					err = d.joinErrors(nested)
+				}
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			if err = d.fieldError(errs, err, elem, srcElem, dstField); err != nil {
+				return err
+			}
+			continue
+		}
+		commit()
+	}
 	return nil
 }
 
+// hasMissingRules reports whether typ is a struct with required or defaulted
+// fields, or nested structs having them.
+func (d *decoder) hasMissingRules(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct || d.isSpecialType(typ) {
+		return false
+	}
+	for _, f := range d.structFields(typ) {
+		if f.IsExported() && (f.tag.required || f.tag.hasDefault || d.hasMissingRules(f.Type)) {
+			return true
+		}
+	}
+	return false
+}
+
 func toTimeDuration(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
@@ -550,27 +915,34 @@ func toTimeDuration(src, dst reflect.Value) error {
 	return nil
 }
 
-func toTimeTime(src, dst reflect.Value) error {
+func (d *decoder) toTimeTime(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
-		t, err := time.Parse(TimeLayout, s)
+		t, err := d.parseTime(s)
 		if err != nil {
 			return err
 		}
 		dst.Set(reflect.ValueOf(t))
 
 	case reflect.Interface, reflect.Ptr:
-		return toTimeTime(indirect(src), dst)
+		return d.toTimeTime(indirect(src), dst)
+
+	case reflect.Struct:
+		if src.Type() == dst.Type() {
+			dst.Set(src)
+			return nil
+		}
+		return d.toStruct(src, dst)
 
 	default:
-		return toStruct(src, dst)
+		return d.toStruct(src, dst)
 	}
 
 	return nil
 }
 
-func toByteSize(src, dst reflect.Value) error {
+func (d *decoder) toByteSize(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
@@ -581,10 +953,10 @@ func toByteSize(src, dst reflect.Value) error {
 		dst.Set(reflect.ValueOf(ByteSize(bs)))
 
 	case reflect.Interface, reflect.Ptr:
-		return toByteSize(indirect(src), dst)
+		return d.toByteSize(indirect(src), dst)
 
 	default:
-		return toStruct(src, dst)
+		return d.toStruct(src, dst)
 	}
 
 	return nil
@@ -630,7 +1002,7 @@ func toNetIP(src, dst reflect.Value) error {
 	}
 }
 
-func toNetURL(src, dst reflect.Value) error {
+func (d *decoder) toNetURL(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
@@ -642,14 +1014,14 @@ func toNetURL(src, dst reflect.Value) error {
 		return nil
 
 	case reflect.Interface, reflect.Ptr:
-		return toNetURL(indirect(src), dst)
+		return d.toNetURL(indirect(src), dst)
 
 	default:
-		return toStruct(src, dst)
+		return d.toStruct(src, dst)
 	}
 }
 
-func toMailAddress(src, dst reflect.Value) error {
+func (d *decoder) toMailAddress(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
@@ -661,14 +1033,14 @@ func toMailAddress(src, dst reflect.Value) error {
 		return nil
 
 	case reflect.Interface, reflect.Ptr:
-		return toMailAddress(indirect(src), dst)
+		return d.toMailAddress(indirect(src), dst)
 
 	default:
-		return toStruct(src, dst)
+		return d.toStruct(src, dst)
 	}
 }
 
-func toRegexpRegexp(src, dst reflect.Value) error {
+func (d *decoder) toRegexpRegexp(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
@@ -681,10 +1053,10 @@ func toRegexpRegexp(src, dst reflect.Value) error {
 		return nil
 
 	case reflect.Interface, reflect.Ptr:
-		return toRegexpRegexp(indirect(src), dst)
+		return d.toRegexpRegexp(indirect(src), dst)
 
 	default:
-		return toStruct(src, dst)
+		return d.toStruct(src, dst)
 	}
 }
 
@@ -938,6 +1310,13 @@ func weakToComplex(src, dst reflect.Value) error {
 }
 
 func weakToString(src, dst reflect.Value) error {
+	if text, ok, err := marshalText(src); ok {
+		if err != nil {
+			return err
+		}
+		dst.SetString(text)
+		return nil
+	}
 	if src.Type().Implements(stringerType) {
 		string, _ := src.Type().MethodByName("String")
 		s := string.Func.Call([]reflect.Value{src})[0].String()
@@ -975,7 +1354,7 @@ func weakToString(src, dst reflect.Value) error {
 		s = fmt.Sprintf("0x%x", src.UnsafePointer())
 
 	case reflect.Interface, reflect.Pointer:
-		toString(indirect(src), dst)
+		return weakToString(indirect(src), dst)
 
 	default:
 		// If you call String of other type, it's better to
@@ -1008,27 +1387,34 @@ func weakToTimeDuration(src, dst reflect.Value) error {
 	return nil
 }
 
-func weakToTimeTime(src, dst reflect.Value) error {
+func (d *decoder) weakToTimeTime(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
-		t, err := time.Parse(TimeLayout, s)
+		t, err := d.parseTime(s)
 		if err != nil {
 			return err
 		}
 		dst.Set(reflect.ValueOf(t))
 
 	case reflect.Interface, reflect.Ptr:
-		return weakToTimeTime(indirect(src), dst)
+		return d.weakToTimeTime(indirect(src), dst)
+
+	case reflect.Struct:
+		if src.Type().ConvertibleTo(dst.Type()) {
+			dst.Set(src.Convert(dst.Type()))
+			return nil
+		}
+		return d.weakToStruct(src, dst)
 
 	default:
-		return weakToStruct(src, dst)
+		return d.weakToStruct(src, dst)
 	}
 
 	return nil
 }
 
-func weakToNetIP(src, dst reflect.Value) error {
+func (d *decoder) weakToNetIP(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
@@ -1040,15 +1426,15 @@ func weakToNetIP(src, dst reflect.Value) error {
 		return nil
 
 	case reflect.Interface, reflect.Ptr:
-		return weakToNetIP(indirect(src), dst)
+		return d.weakToNetIP(indirect(src), dst)
 
 	// TODO: toBytes(src, dst)
 	default:
-		return weakToSlice(src, dst)
+		return d.weakToSlice(src, dst)
 	}
 }
 
-func weakToNetHardwareAddr(src, dst reflect.Value) error {
+func (d *decoder) weakToNetHardwareAddr(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
@@ -1060,15 +1446,15 @@ func weakToNetHardwareAddr(src, dst reflect.Value) error {
 		return nil
 
 	case reflect.Interface, reflect.Ptr:
-		return weakToNetHardwareAddr(indirect(src), dst)
+		return d.weakToNetHardwareAddr(indirect(src), dst)
 
 	// TODO: toBytes(src, dst)
 	default:
-		return weakToSlice(src, dst)
+		return d.weakToSlice(src, dst)
 	}
 }
 
-func weakToNetURL(src, dst reflect.Value) error {
+func (d *decoder) weakToNetURL(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
@@ -1080,14 +1466,14 @@ func weakToNetURL(src, dst reflect.Value) error {
 		return nil
 
 	case reflect.Interface, reflect.Ptr:
-		return weakToNetURL(indirect(src), dst)
+		return d.weakToNetURL(indirect(src), dst)
 
 	default:
-		return weakToStruct(src, dst)
+		return d.weakToStruct(src, dst)
 	}
 }
 
-func weakToMailAddress(src, dst reflect.Value) error {
+func (d *decoder) weakToMailAddress(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
@@ -1099,14 +1485,14 @@ func weakToMailAddress(src, dst reflect.Value) error {
 		return nil
 
 	case reflect.Interface, reflect.Ptr:
-		return weakToMailAddress(indirect(src), dst)
+		return d.weakToMailAddress(indirect(src), dst)
 
 	default:
-		return weakToStruct(src, dst)
+		return d.weakToStruct(src, dst)
 	}
 }
 
-func weakToRegexpRegexp(src, dst reflect.Value) error {
+func (d *decoder) weakToRegexpRegexp(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
@@ -1119,14 +1505,14 @@ func weakToRegexpRegexp(src, dst reflect.Value) error {
 		return nil
 
 	case reflect.Interface, reflect.Ptr:
-		return weakToRegexpRegexp(indirect(src), dst)
+		return d.weakToRegexpRegexp(indirect(src), dst)
 
 	default:
-		return weakToStruct(src, dst)
+		return d.weakToStruct(src, dst)
 	}
 }
 
-func weakToByteSize(src, dst reflect.Value) error {
+func (d *decoder) weakToByteSize(src, dst reflect.Value) error {
 	switch src.Kind() {
 	case reflect.String:
 		s := src.String()
@@ -1137,35 +1523,35 @@ func weakToByteSize(src, dst reflect.Value) error {
 		dst.Set(reflect.ValueOf(ByteSize(bs)))
 
 	case reflect.Interface, reflect.Ptr:
-		return weakToByteSize(indirect(src), dst)
+		return d.weakToByteSize(indirect(src), dst)
 
 	default:
-		return weakToStruct(src, dst)
+		return d.weakToStruct(src, dst)
 	}
 
 	return nil
 }
 
-func weakToStruct(src, dst reflect.Value) error {
-	return toStruct0(src, dst, weakTo0)
+func (d *decoder) weakToStruct(src, dst reflect.Value) error {
+	return d.toStruct0(src, dst, d.weakTo0)
 }
 
-func weakToMap(src, dst reflect.Value) error {
-	return toMap0(src, dst, weakTo0)
+func (d *decoder) weakToMap(src, dst reflect.Value) error {
+	return d.toMap0(src, dst, d.weakTo0)
 }
 
-func weakToSlice(src, dst reflect.Value) error {
-	return toSlice0(src, dst, weakTo0)
+func (d *decoder) weakToSlice(src, dst reflect.Value) error {
+	return d.toSlice0(src, dst, d.weakTo0)
 }
 
-func weakToArray(src, dst reflect.Value) error {
-	return toArray0(src, dst, weakTo0)
+func (d *decoder) weakToArray(src, dst reflect.Value) error {
+	return d.toArray0(src, dst, d.weakTo0)
 }
 
-func weakToInterface(src, dst reflect.Value) error {
-	return errors.New("not implement")
+func (d *decoder) weakToInterface(src, dst reflect.Value) error {
+	return d.toInterface(src, dst)
 }
 
-func weakToPtr(src, dst reflect.Value) error {
-	return errors.New("not implement")
+func (d *decoder) weakToPtr(src, dst reflect.Value) error {
+	return d.toPtr0(src, dst, d.weakTo0)
 }
@@ -0,0 +1,94 @@
+package conv
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// DefaultKeyMatcher matches key case sensitively if it contains upper case letters,
+// otherwise case insensitively.
+func DefaultKeyMatcher(key string, field reflect.StructField) bool {
+	if key != strings.ToLower(key) {
+		return key == field.Name
+	}
+	return key == strings.ToLower(field.Name)
+}
+
+// ExactKeyMatcher matches key equal to the field name.
+func ExactKeyMatcher(key string, field reflect.StructField) bool {
+	return key == field.Name
+}
+
+// CaseInsensitiveKeyMatcher matches key equal to the field name under case folding,
+// e.g. "maxconns" (as lowercased by viper) matches MaxConns.
+func CaseInsensitiveKeyMatcher(key string, field reflect.StructField) bool {
+	return strings.EqualFold(key, field.Name)
+}
+
+// SnakeCaseKeyMatcher matches key equal to the field name in snake_case,
+// e.g. "max_conns" matches MaxConns, "http_port" matches HTTPPort.
+func SnakeCaseKeyMatcher(key string, field reflect.StructField) bool {
+	return key == strings.Join(splitWords(field.Name), "_")
+}
+
+// KebabCaseKeyMatcher matches key equal to the field name in kebab-case,
+// e.g. "max-conns" matches MaxConns.
+func KebabCaseKeyMatcher(key string, field reflect.StructField) bool {
+	return key == strings.Join(splitWords(field.Name), "-")
+}
+
+// CamelCaseKeyMatcher matches key equal to the field name in camelCase,
+// e.g. "maxConns" matches MaxConns, "httpPort" matches HTTPPort.
+func CamelCaseKeyMatcher(key string, field reflect.StructField) bool {
+	words := splitWords(field.Name)
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return key == strings.Join(words, "")
+}
+
+// AnyKeyMatcher returns a KeyMatcher matching key if any of matchers does.
+func AnyKeyMatcher(matchers ...KeyMatcher) KeyMatcher {
+	return func(key string, field reflect.StructField) bool {
+		for _, m := range matchers {
+			if m(key, field) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// splitWords splits the identifier name into lower case words at case changes
+// and separators, e.g. "HTTPServer_v2" into "http", "server" and "v2".
+func splitWords(name string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '_' || r == '-' || unicode.IsSpace(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = word[:0]
+			}
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && next {
+				words = append(words, string(word))
+				word = word[:0]
+			}
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
@@ -0,0 +1,46 @@
+package conv
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Metadata reports how the source maps and structs were converted to structs.
+// The keys and fields are paths like FieldError.Path, the source struct
+// fields are named by their keys.
+type Metadata struct {
+	Keys   []string // source keys (or fields) converted to a field
+	Unused []string // source keys (or fields) matching no field
+	Unset  []string // destination fields no source key is converted to
+}
+
+// ToWithMetadata convert src to dst like To, and reports the metadata of the conversion
+func ToWithMetadata(src, dst interface{}) (Metadata, error) {
+	return defaultConverter.ToWithMetadata(src, dst)
+}
+
+// WeakToWithMetadata convert src to dst like WeakTo, and reports the metadata of the conversion
+func WeakToWithMetadata(src, dst interface{}) (Metadata, error) {
+	return defaultWeakConverter.ToWithMetadata(src, dst)
+}
+
+// ToWithMetadata convert src to dst like To, and reports the metadata of the conversion
+func (c *Converter) ToWithMetadata(src, dst interface{}) (Metadata, error) {
+	d := &decoder{Converter: c, meta: &Metadata{}}
+	err := c.decode(d, src, dst)
+
+	sort.Strings(d.meta.Keys)
+	sort.Strings(d.meta.Unused)
+	sort.Strings(d.meta.Unset)
+	return *d.meta, err
+}
+
+// recordUnset records the exported fields of the struct type typ not
+// overlapping the fields of indexes set.
+func (d *decoder) recordUnset(typ reflect.Type, set [][]int) {
+	for _, f := range d.structFields(typ) {
+		if f.IsExported() && !overlaps(f.Index, set) {
+			d.meta.Unset = append(d.meta.Unset, d.pathOf(f.key))
+		}
+	}
+}
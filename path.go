@@ -0,0 +1,70 @@
+package conv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// pathElem is an element of the path to a value in a composite value,
+// a map key, a field name or an index.
+type pathElem struct {
+	name  string
+	index int // -1 if it's not an index
+}
+
+func indexElem(i int) pathElem {
+	return pathElem{index: i}
+}
+
+func keyElem(key reflect.Value) pathElem {
+	key = indirect(key)
+	if key.Kind() == reflect.String {
+		return nameElem(key.String())
+	}
+	return nameElem(fmt.Sprintf("[%v]", key))
+}
+
+func nameElem(name string) pathElem {
+	return pathElem{name: name, index: -1}
+}
+
+func (e pathElem) String() string {
+	if e.index >= 0 {
+		return "[" + strconv.Itoa(e.index) + "]"
+	}
+	return e.name
+}
+
+func joinPath(name, path string) string {
+	if name == "" {
+		return path
+	}
+	if strings.HasPrefix(path, "[") {
+		return name + path
+	}
+	return name + "." + path
+}
+
+// toElem converts src to dst, the element elem of the composite value being
+// converted, keeping track of the path if it's needed.
+func (d *decoder) toElem(to func(src, dst reflect.Value) error, elem pathElem, src, dst reflect.Value) error {
+	if d.meta == nil {
+		return to(src, dst)
+	}
+
+	d.path = append(d.path, elem)
+	err := to(src, dst)
+	d.path = d.path[:len(d.path)-1]
+	return err
+}
+
+// pathOf returns the path of the element name of the value being converted.
+func (d *decoder) pathOf(name string) string {
+	var path string
+	for _, elem := range d.path {
+		path = joinPath(path, elem.String())
+	}
+	return joinPath(path, name)
+}
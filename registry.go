@@ -0,0 +1,166 @@
+package conv
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ConvFunc converts src to dst. dst is always settable.
+type ConvFunc func(src, dst reflect.Value) error
+
+// convFunc is the registered form of a ConvFunc. The built-in converters
+// use the decoder to honor the options of its Converter.
+type convFunc func(d *decoder, src, dst reflect.Value) error
+
+// EncodeFunc renders v, of a type with a registered converter, in a form
+// that converter converts back. It is used by ToMap.
+type EncodeFunc func(v reflect.Value) (interface{}, error)
+
+// encodeFunc is the registered form of an EncodeFunc.
+type encodeFunc func(c *Converter, v reflect.Value) (interface{}, error)
+
+var (
+	registryMu  sync.RWMutex
+	strictConvs = make(map[reflect.Type]convFunc)
+	weakConvs   = make(map[reflect.Type]convFunc)
+	encoders    = make(map[reflect.Type]encodeFunc)
+)
+
+func init() {
+	builtins := []struct {
+		typ    reflect.Type
+		conv   convFunc
+		weak   convFunc
+		encode encodeFunc
+	}{
+		{reflect.TypeOf(time.Duration(0)), bind(toTimeDuration), bind(weakToTimeDuration), encodeStringer},
+		{reflect.TypeOf(time.Time{}), (*decoder).toTimeTime, (*decoder).weakToTimeTime, encodeTimeTime},
+		{reflect.TypeOf(net.IP(nil)), bind(toNetIP), (*decoder).weakToNetIP, encodeStringer},
+		{reflect.TypeOf(net.HardwareAddr(nil)), bind(toNetHardwareAddr), (*decoder).weakToNetHardwareAddr, encodeStringer},
+		{reflect.TypeOf(url.URL{}), (*decoder).toNetURL, (*decoder).weakToNetURL, encodeStringer},
+		{reflect.TypeOf(mail.Address{}), (*decoder).toMailAddress, (*decoder).weakToMailAddress, encodeStringer},
+		{reflect.TypeOf(regexp.Regexp{}), (*decoder).toRegexpRegexp, (*decoder).weakToRegexpRegexp, encodeStringer},
+		{reflect.TypeOf(ByteSize(0)), (*decoder).toByteSize, (*decoder).weakToByteSize, encodeByteSize},
+	}
+	for _, b := range builtins {
+		register(strictConvs, b.typ, b.conv)
+		register(weakConvs, b.typ, b.weak)
+		registerEncoder(b.typ, b.encode)
+	}
+}
+
+// Register registers fn as the converter used by To for dst of type typ,
+// replacing any previous one (including the built-in ones).
+// A nil fn removes the converter of typ.
+func Register(typ reflect.Type, fn ConvFunc) {
+	register(strictConvs, typ, bind(fn))
+}
+
+// RegisterWeak registers fn as the converter used by WeakTo for dst of type typ,
+// replacing any previous one (including the built-in ones).
+// A nil fn removes the converter of typ.
+func RegisterWeak(typ reflect.Type, fn ConvFunc) {
+	register(weakConvs, typ, bind(fn))
+}
+
+// RegisterEncoder registers fn as the encoder used by ToMap for values of type typ,
+// replacing any previous one (including the built-in ones). Register it along
+// the converter of typ, so that the maps of ToMap convert back with To.
+// A nil fn removes the encoder of typ.
+func RegisterEncoder(typ reflect.Type, fn EncodeFunc) {
+	registerEncoder(typ, bindEncoder(fn))
+}
+
+func register(convs map[reflect.Type]convFunc, typ reflect.Type, fn convFunc) {
+	if typ == nil {
+		panic("conv: Register of nil type")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if fn == nil {
+		delete(convs, typ)
+		return
+	}
+	convs[typ] = fn
+}
+
+func registerEncoder(typ reflect.Type, fn encodeFunc) {
+	if typ == nil {
+		panic("conv: RegisterEncoder of nil type")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if fn == nil {
+		delete(encoders, typ)
+		return
+	}
+	encoders[typ] = fn
+}
+
+// lookupConv returns the converter of typ registered with the Converter,
+// or else with Register or RegisterWeak.
+func (c *Converter) lookupConv(weak bool, typ reflect.Type) (convFunc, bool) {
+	own, convs := c.convs, strictConvs
+	if weak {
+		own, convs = c.weakConvs, weakConvs
+	}
+	if fn, ok := own[typ]; ok {
+		return fn, true
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	fn, ok := convs[typ]
+	return fn, ok
+}
+
+func bind(fn ConvFunc) convFunc {
+	if fn == nil {
+		return nil
+	}
+	return func(_ *decoder, src, dst reflect.Value) error {
+		return fn(src, dst)
+	}
+}
+
+// lookupEncoder returns the encoder of typ registered with the Converter,
+// or else with RegisterEncoder.
+func (c *Converter) lookupEncoder(typ reflect.Type) (encodeFunc, bool) {
+	if fn, ok := c.encoders[typ]; ok {
+		return fn, true
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	fn, ok := encoders[typ]
+	return fn, ok
+}
+
+func bindEncoder(fn EncodeFunc) encodeFunc {
+	if fn == nil {
+		return nil
+	}
+	return func(_ *Converter, v reflect.Value) (interface{}, error) {
+		return fn(v)
+	}
+}
+
+// isSpecialType reports whether typ has a registered converter.
+func (c *Converter) isSpecialType(typ reflect.Type) bool {
+	if _, ok := c.lookupConv(false, typ); ok {
+		return true
+	}
+	_, ok := c.lookupConv(true, typ)
+	return ok
+}
@@ -0,0 +1,273 @@
+package conv
+
+import (
+	"reflect"
+	"strings"
+)
+
+// fieldTag is the parsed struct tag of a field,
+// e.g. `conv:"name,omitempty"`, `conv:"-"`,
+// `conv:"name,required"` or `conv:"name,default=30s"`.
+// The default option must be the last one, its value may contain commas.
+// A null source value (e.g. a YAML "~") of a required or defaulted field
+// is missing, whatever the NullPolicy. The fields of a missing nested struct
+// (a section missing in the source map or struct) are missing too.
+type fieldTag struct {
+	name      string
+	skip      bool
+	omitEmpty bool
+	required  bool
+
+	hasDefault bool
+	def        string
+}
+
+// structField is a field of a struct, Index is relative to the outermost struct.
+type structField struct {
+	reflect.StructField
+	key string // name given by the struct tag, or the field name
+	tag fieldTag
+}
+
+func (c *Converter) parseTag(field reflect.StructField) fieldTag {
+	tag, ok := field.Tag.Lookup(c.tagName)
+	if !ok {
+		return fieldTag{}
+	}
+	if tag == "-" {
+		return fieldTag{skip: true}
+	}
+
+	var ft fieldTag
+	if i := strings.Index(tag, ",default="); i >= 0 {
+		ft.hasDefault = true
+		ft.def = tag[i+len(",default="):]
+		tag = tag[:i]
+	}
+
+	opts := strings.Split(tag, ",")
+	ft.name = opts[0]
+	for _, opt := range opts[1:] {
+		switch opt {
+		case "omitempty":
+			ft.omitEmpty = true
+		case "squash", "inline":
+			// embedded structs are squashed by default
+		case "required":
+			ft.required = true
+		}
+	}
+	return ft
+}
+
+// structInfo is the fields of a struct type, cached by the Converter.
+type structInfo struct {
+	fields    []structField
+	conflicts map[string][]structField // fields of a key hiding each other
+}
+
+// structFields returns the fields of the struct type typ by the key they are
+// converted from and to.
+func (c *Converter) structFields(typ reflect.Type) []structField {
+	return c.structInfo(typ).fields
+}
+
+// structInfo returns the fields of the struct type typ. Embedded structs are
+// squashed, replaced by their fields, unless they're named by the struct tag
+// or have a registered converter. Like Go promoted fields, the shallowest
+// field of a key wins, and the fields of the same depth hide each other.
+func (c *Converter) structInfo(typ reflect.Type) *structInfo {
+	if info, ok := c.fieldCache.Load(typ); ok {
+		return info.(*structInfo)
+	}
+
+	var all []structField
+	c.walkFields(typ, nil, map[reflect.Type]bool{typ: true}, &all)
+
+	depth := make(map[string]int, len(all))
+	count := make(map[string]int, len(all))
+	for _, f := range all {
+		d, ok := depth[f.key]
+		switch {
+		case !ok || len(f.Index) < d:
+			depth[f.key] = len(f.Index)
+			count[f.key] = 1
+		case len(f.Index) == d:
+			count[f.key]++
+		}
+	}
+
+	info := &structInfo{fields: make([]structField, 0, len(all))}
+	for _, f := range all {
+		switch {
+		case len(f.Index) != depth[f.key]:
+		case count[f.key] == 1:
+			info.fields = append(info.fields, f)
+		default:
+			if info.conflicts == nil {
+				info.conflicts = make(map[string][]structField)
+			}
+			info.conflicts[f.key] = append(info.conflicts[f.key], f)
+		}
+	}
+
+	c.fieldCache.Store(typ, info)
+	return info
+}
+
+func (c *Converter) walkFields(typ reflect.Type, index []int, visited map[reflect.Type]bool, fields *[]structField) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := c.parseTag(field)
+		if tag.skip {
+			continue
+		}
+		field.Index = append(index[:len(index):len(index)], i)
+
+		key := field.Name
+		if tag.name != "" {
+			key = tag.name
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if !field.Anonymous || tag.name != "" || ft.Kind() != reflect.Struct || c.isSpecialType(ft) {
+			*fields = append(*fields, structField{field, key, tag})
+			continue
+		}
+
+		if !visited[ft] {
+			visited[ft] = true
+			c.walkFields(ft, field.Index, visited, fields)
+			delete(visited, ft)
+		}
+	}
+}
+
+func isPrefix(prefix, index []int) bool {
+	if len(prefix) > len(index) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != index[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps reports whether the field of index is, contains or is contained
+// by one of the fields of indexes.
+func overlaps(index []int, indexes [][]int) bool {
+	for _, other := range indexes {
+		if isPrefix(index, other) || isPrefix(other, index) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldConflict returns a FieldConflictError if key matches fields of the
+// struct type typ hiding each other.
+func (c *Converter) fieldConflict(typ reflect.Type, key string) error {
+	for _, fields := range c.structInfo(typ).conflicts {
+		named := fields[0].StructField
+		named.Name = fields[0].key
+		if !c.keyMatcher(key, named) {
+			continue
+		}
+
+		paths := make([]string, len(fields))
+		for i, f := range fields {
+			paths[i] = fieldPath(typ, f.Index)
+		}
+		return &FieldConflictError{typ, key, paths}
+	}
+	return nil
+}
+
+// fieldPath returns the Go selector of the field of index, e.g. "Base.ID".
+func fieldPath(typ reflect.Type, index []int) string {
+	names := make([]string, len(index))
+	for i, x := range index {
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		field := typ.Field(x)
+		names[i] = field.Name
+		typ = field.Type
+	}
+	return strings.Join(names, ".")
+}
+
+// structField returns the field of the struct type typ matched by key.
+// Unexported fields cannot be set, so they're never matched.
+func (c *Converter) structField(typ reflect.Type, key string) (structField, bool) {
+	for _, field := range c.structFields(typ) {
+		if !field.IsExported() {
+			continue
+		}
+		named := field.StructField
+		named.Name = field.key
+		if c.keyMatcher(key, named) {
+			return field, true
+		}
+	}
+	return structField{}, false
+}
+
+// fieldByIndex is like v.FieldByIndex, but nil embedded pointers are
+// allocated in a temporary value, set to them by commit once the field
+// is converted, so that they stay nil if it fails.
+func fieldByIndex(v reflect.Value, index []int) (field reflect.Value, commit func(), err error) {
+	var (
+		name       string
+		ptr, alloc reflect.Value // the outermost nil pointer and its temporary value
+	)
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, nil, &CannotSetError{name, v.Type()}
+				}
+				elem := reflect.New(v.Type().Elem())
+				if alloc.IsValid() {
+					v.Set(elem) // within the temporary value
+				} else {
+					ptr, alloc = v, elem
+				}
+				v = elem
+			}
+			v = v.Elem()
+		}
+		name = v.Type().Field(x).Name
+		v = v.Field(x)
+	}
+
+	commit = func() {}
+	if alloc.IsValid() {
+		commit = func() { ptr.Set(alloc) }
+	}
+	return v, commit, nil
+}
+
+// isEmptyValue reports whether v is empty for the omitempty tag option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
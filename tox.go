@@ -0,0 +1,186 @@
+package conv
+
+import (
+	"net"
+	"time"
+)
+
+// ToInt64 convert src to an int64
+func ToInt64(src interface{}) (int64, error) {
+	return ToT[int64](src)
+}
+
+// ToInt64Or convert src to an int64, returns def if failed
+func ToInt64Or(src interface{}, def int64) int64 {
+	return convOr(defaultConverter, src, def)
+}
+
+// WeakToInt64 convert src to an int64 (weak type convert)
+func WeakToInt64(src interface{}) (int64, error) {
+	return WeakToT[int64](src)
+}
+
+// WeakToInt64Or convert src to an int64 (weak type convert), returns def if failed
+func WeakToInt64Or(src interface{}, def int64) int64 {
+	return convOr(defaultWeakConverter, src, def)
+}
+
+// ToUint convert src to a uint
+func ToUint(src interface{}) (uint, error) {
+	return ToT[uint](src)
+}
+
+// ToUintOr convert src to a uint, returns def if failed
+func ToUintOr(src interface{}, def uint) uint {
+	return convOr(defaultConverter, src, def)
+}
+
+// WeakToUint convert src to a uint (weak type convert)
+func WeakToUint(src interface{}) (uint, error) {
+	return WeakToT[uint](src)
+}
+
+// WeakToUintOr convert src to a uint (weak type convert), returns def if failed
+func WeakToUintOr(src interface{}, def uint) uint {
+	return convOr(defaultWeakConverter, src, def)
+}
+
+// ToFloat64 convert src to a float64
+func ToFloat64(src interface{}) (float64, error) {
+	return ToT[float64](src)
+}
+
+// ToFloat64Or convert src to a float64, returns def if failed
+func ToFloat64Or(src interface{}, def float64) float64 {
+	return convOr(defaultConverter, src, def)
+}
+
+// WeakToFloat64 convert src to a float64 (weak type convert)
+func WeakToFloat64(src interface{}) (float64, error) {
+	return WeakToT[float64](src)
+}
+
+// WeakToFloat64Or convert src to a float64 (weak type convert), returns def if failed
+func WeakToFloat64Or(src interface{}, def float64) float64 {
+	return convOr(defaultWeakConverter, src, def)
+}
+
+// ToBool convert src to a bool
+func ToBool(src interface{}) (bool, error) {
+	return ToT[bool](src)
+}
+
+// ToBoolOr convert src to a bool, returns def if failed
+func ToBoolOr(src interface{}, def bool) bool {
+	return convOr(defaultConverter, src, def)
+}
+
+// WeakToBool convert src to a bool (weak type convert)
+func WeakToBool(src interface{}) (bool, error) {
+	return WeakToT[bool](src)
+}
+
+// WeakToBoolOr convert src to a bool (weak type convert), returns def if failed
+func WeakToBoolOr(src interface{}, def bool) bool {
+	return convOr(defaultWeakConverter, src, def)
+}
+
+// ToString convert src to a string
+func ToString(src interface{}) (string, error) {
+	return ToT[string](src)
+}
+
+// ToStringOr convert src to a string, returns def if failed
+func ToStringOr(src interface{}, def string) string {
+	return convOr(defaultConverter, src, def)
+}
+
+// WeakToString convert src to a string (weak type convert)
+func WeakToString(src interface{}) (string, error) {
+	return WeakToT[string](src)
+}
+
+// WeakToStringOr convert src to a string (weak type convert), returns def if failed
+func WeakToStringOr(src interface{}, def string) string {
+	return convOr(defaultWeakConverter, src, def)
+}
+
+// ToDuration convert src to a time.Duration
+func ToDuration(src interface{}) (time.Duration, error) {
+	return ToT[time.Duration](src)
+}
+
+// ToDurationOr convert src to a time.Duration, returns def if failed
+func ToDurationOr(src interface{}, def time.Duration) time.Duration {
+	return convOr(defaultConverter, src, def)
+}
+
+// WeakToDuration convert src to a time.Duration (weak type convert)
+func WeakToDuration(src interface{}) (time.Duration, error) {
+	return WeakToT[time.Duration](src)
+}
+
+// WeakToDurationOr convert src to a time.Duration (weak type convert), returns def if failed
+func WeakToDurationOr(src interface{}, def time.Duration) time.Duration {
+	return convOr(defaultWeakConverter, src, def)
+}
+
+// ToTime convert src to a time.Time
+func ToTime(src interface{}) (time.Time, error) {
+	return ToT[time.Time](src)
+}
+
+// ToTimeOr convert src to a time.Time, returns def if failed
+func ToTimeOr(src interface{}, def time.Time) time.Time {
+	return convOr(defaultConverter, src, def)
+}
+
+// WeakToTime convert src to a time.Time (weak type convert)
+func WeakToTime(src interface{}) (time.Time, error) {
+	return WeakToT[time.Time](src)
+}
+
+// WeakToTimeOr convert src to a time.Time (weak type convert), returns def if failed
+func WeakToTimeOr(src interface{}, def time.Time) time.Time {
+	return convOr(defaultWeakConverter, src, def)
+}
+
+// ToIP convert src to a net.IP
+func ToIP(src interface{}) (net.IP, error) {
+	return ToT[net.IP](src)
+}
+
+// ToIPOr convert src to a net.IP, returns def if failed
+func ToIPOr(src interface{}, def net.IP) net.IP {
+	return convOr(defaultConverter, src, def)
+}
+
+// WeakToIP convert src to a net.IP (weak type convert)
+func WeakToIP(src interface{}) (net.IP, error) {
+	return WeakToT[net.IP](src)
+}
+
+// WeakToIPOr convert src to a net.IP (weak type convert), returns def if failed
+func WeakToIPOr(src interface{}, def net.IP) net.IP {
+	return convOr(defaultWeakConverter, src, def)
+}
+
+// ToByteSize convert src to a ByteSize
+func ToByteSize(src interface{}) (ByteSize, error) {
+	return ToT[ByteSize](src)
+}
+
+// ToByteSizeOr convert src to a ByteSize, returns def if failed
+func ToByteSizeOr(src interface{}, def ByteSize) ByteSize {
+	return convOr(defaultConverter, src, def)
+}
+
+// WeakToByteSize convert src to a ByteSize (weak type convert)
+func WeakToByteSize(src interface{}) (ByteSize, error) {
+	return WeakToT[ByteSize](src)
+}
+
+// WeakToByteSizeOr convert src to a ByteSize (weak type convert), returns def if failed
+func WeakToByteSizeOr(src interface{}, def ByteSize) ByteSize {
+	return convOr(defaultWeakConverter, src, def)
+}
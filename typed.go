@@ -0,0 +1,50 @@
+package conv
+
+import "reflect"
+
+// ToT convert src to a value of type T
+func ToT[T any](src interface{}) (T, error) {
+	return convT[T](defaultConverter, src)
+}
+
+// WeakToT convert src to a value of type T (weak type convert)
+func WeakToT[T any](src interface{}) (T, error) {
+	return convT[T](defaultWeakConverter, src)
+}
+
+// MustToT is like ToT but panics if the conversion fails.
+func MustToT[T any](src interface{}) T {
+	return must(ToT[T](src))
+}
+
+// MustWeakToT is like WeakToT but panics if the conversion fails.
+func MustWeakToT[T any](src interface{}) T {
+	return must(WeakToT[T](src))
+}
+
+func convT[T any](c *Converter, src interface{}) (T, error) {
+	var dst T
+	if err := c.To(src, &dst); err != nil {
+		var zero T
+		return zero, err
+	}
+	return dst, nil
+}
+
+// convOr returns def if src is null or fails to convert.
+func convOr[T any](c *Converter, src interface{}, def T) T {
+	if isNull(reflect.ValueOf(src)) {
+		return def
+	}
+	if dst, err := convT[T](c, src); err == nil {
+		return dst
+	}
+	return def
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,64 @@
+package conv
+
+import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"reflect"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// unmarshal converts the string src with the parser of dst, if dst or its
+// address implements encoding.TextUnmarshaler, flag.Value or json.Unmarshaler
+// (given src as a JSON string), and reports whether it did.
+func unmarshal(src, dst reflect.Value) (bool, error) {
+	for src.Kind() == reflect.Interface || src.Kind() == reflect.Ptr {
+		src = src.Elem()
+	}
+	if src.Kind() != reflect.String || dst.Kind() == reflect.Ptr || dst.Kind() == reflect.Interface {
+		return false, nil
+	}
+
+	s := src.String()
+	switch u := dst.Addr().Interface().(type) {
+	case encoding.TextUnmarshaler:
+		return true, u.UnmarshalText([]byte(s))
+
+	case flag.Value:
+		return true, u.Set(s)
+
+	case json.Unmarshaler:
+		data, err := json.Marshal(s)
+		if err != nil {
+			return true, err
+		}
+		return true, u.UnmarshalJSON(data)
+
+	default:
+		return false, nil
+	}
+}
+
+// isTextMarshaler reports whether typ or its pointer implements
+// encoding.TextMarshaler.
+func isTextMarshaler(typ reflect.Type) bool {
+	return typ.Implements(textMarshalerType) ||
+		typ.Kind() != reflect.Ptr && reflect.PtrTo(typ).Implements(textMarshalerType)
+}
+
+// marshalText returns the text of v, if v or its address implements
+// encoding.TextMarshaler, and reports whether it did.
+func marshalText(v reflect.Value) (string, bool, error) {
+	if !v.CanInterface() || !isTextMarshaler(v.Type()) || v.Kind() == reflect.Ptr && v.IsNil() {
+		return "", false, nil
+	}
+
+	if !v.Type().Implements(textMarshalerType) {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	return string(text), true, err
+}
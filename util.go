@@ -16,24 +16,22 @@ func indirect(v reflect.Value) reflect.Value {
 	}
 }
 
-func mapIndex(m, key reflect.Value) reflect.Value {
-	val := m.MapIndex(key)
-	if val.Kind() != reflect.Invalid {
-		return val
-	}
-	val = reflect.New(m.Type().Elem())
-	return val.Elem()
-}
-
-func sliceIndex(slice reflect.Value, i int) (elem reflect.Value) {
-	if i >= slice.Len() {
-		elem = reflect.New(slice.Type().Elem())
-		newSlice := reflect.Append(slice, slice.Elem())
-		if slice.UnsafeAddr() != newSlice.UnsafeAddr() {
-			slice.Set(newSlice)
+// isNull reports whether v is invalid, a nil interface or a nil pointer,
+// possibly behind other interfaces and pointers.
+func isNull(v reflect.Value) bool {
+	for {
+		switch v.Kind() {
+		case reflect.Invalid:
+			return true
+		case reflect.Interface, reflect.Ptr:
+			if v.IsNil() {
+				return true
+			}
+			v = v.Elem()
+		default:
+			return false
 		}
 	}
-	return slice.Index(i)
 }
 
 func isOverflowInt(src, dst reflect.Value) bool {
@@ -0,0 +1,98 @@
+package conv
+
+import "reflect"
+
+// DefaultMaxDepth is the max depth of nested values converted, see WithMaxDepth.
+const DefaultMaxDepth = 10000
+
+// visit is a source pointer or map converted to a destination type.
+// Slices aren't visited, not to share the backing array of their copies.
+type visit struct {
+	ptr uintptr
+	src reflect.Type
+	dst reflect.Type
+}
+
+func newVisit(src reflect.Value, dst reflect.Type) (visit, bool) {
+	for src.Kind() == reflect.Interface && !src.IsNil() {
+		src = src.Elem()
+	}
+
+	switch src.Kind() {
+	case reflect.Ptr, reflect.Map:
+		if src.IsNil() {
+			return visit{}, false
+		}
+		return visit{src.Pointer(), src.Type(), dst}, true
+
+	default:
+		return visit{}, false
+	}
+}
+
+// visited sets dst to the value src was converted to, if it was already,
+// so that shared and cyclic references are preserved.
+func (d *decoder) visited(src, dst reflect.Value) bool {
+	v, ok := newVisit(src, dst.Type())
+	if !ok {
+		return false
+	}
+	if prev, ok := d.visits[v]; ok {
+		dst.Set(prev)
+		return true
+	}
+	return false
+}
+
+// visit records that src is converted to dst.
+func (d *decoder) visit(src, dst reflect.Value) {
+	v, ok := newVisit(src, dst.Type())
+	if !ok {
+		return
+	}
+	if d.visits == nil {
+		d.visits = make(map[visit]reflect.Value)
+	}
+	d.visits[v] = reflect.ValueOf(dst.Interface()) // not the changing dst
+}
+
+// existingVisit is a source pointer converted into an existing pointer.
+type existingVisit struct {
+	visit
+	dst uintptr
+}
+
+// visitExisting records that src is converted into the existing pointer dst,
+// and reports whether it already was, so that cycles converted into an
+// existing destination (e.g. reloaded) terminate.
+func (d *decoder) visitExisting(src, dst reflect.Value) bool {
+	v, ok := newVisit(src, dst.Type())
+	if !ok {
+		return false
+	}
+	ev := existingVisit{v, dst.Pointer()}
+	if d.existing[ev] {
+		return true
+	}
+	if d.existing == nil {
+		d.existing = make(map[existingVisit]bool)
+	}
+	d.existing[ev] = true
+	d.visit(src, dst)
+	return false
+}
+
+// enter increments the depth of the value being converted, failing with
+// a MaxDepthError beyond the max depth.
+func (d *decoder) enter() error {
+	d.depth++
+	if d.maxDepth > 0 && d.depth > d.maxDepth {
+		d.depth--
+		return &MaxDepthError{d.maxDepth}
+	}
+	return nil
+}
+
+func (d *decoder) leave() {
+	d.depth--
+}